@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/sorae42/ressdit/pkg/cache"
+)
+
+// newCache returns the cache configured by CACHE_BACKEND, or nil when caching is disabled.
+func newCache() (cache.Cache, error) {
+	redisURL := os.Getenv("FLY_REDIS_CACHE_URL")
+
+	backend := os.Getenv("CACHE_BACKEND")
+	if backend == "" {
+		backend = "memory"
+		if redisURL != "" {
+			backend = "redis"
+		}
+	}
+
+	switch backend {
+	case "none":
+		return nil, nil
+	case "memory":
+		maxMB := 64
+		if s := os.Getenv("CACHE_MAX_MB"); s != "" {
+			var err error
+			maxMB, err = strconv.Atoi(s)
+			if err != nil {
+				return nil, err
+			}
+		}
+		log.Printf("Caching feeds in memory, up to %dMB", maxMB)
+		return cache.NewMemory(int64(maxMB)<<20, time.Now), nil
+	case "disk":
+		dir := os.Getenv("CACHE_DIR")
+		if dir == "" {
+			return nil, fmt.Errorf("CACHE_BACKEND=disk requires CACHE_DIR")
+		}
+		disk, err := cache.NewDisk(dir, time.Now)
+		if err != nil {
+			return nil, err
+		}
+		go disk.Maintain(time.Hour, log.Printf, nil)
+		log.Printf("Caching feeds in %s", dir)
+		return disk, nil
+	case "redis":
+		if redisURL == "" {
+			return nil, fmt.Errorf("CACHE_BACKEND=redis requires FLY_REDIS_CACHE_URL")
+		}
+		log.Println("Caching feeds in redis")
+		return cache.NewRedis(redisURL)
+	default:
+		return nil, fmt.Errorf("unknown CACHE_BACKEND %q, expected memory, disk, redis or none", backend)
+	}
+}
+
+// durationEnv returns the duration set in the environment variable key, or def when it is not set.
+func durationEnv(key string, def time.Duration) time.Duration {
+	s := os.Getenv(key)
+	if s == "" {
+		return def
+	}
+
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		log.Fatalf("%s: %s", key, err.Error())
+	}
+	return d
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"html/template"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/sorae42/ressdit/pkg/auth"
+	"github.com/sorae42/ressdit/pkg/client"
+	"github.com/sorae42/ressdit/pkg/store"
+)
+
+// stateCookie remembers the state a browser was sent to Reddit with, until it comes back to /auth/callback.
+const stateCookie = "ressdit_state"
+
+var privateFeedsPage = template.Must(template.New("feeds").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>Private feeds of u/{{.Name}}</title></head>
+<body>
+<h1>Private feeds of u/{{.Name}}</h1>
+<p>Anyone with these links can read your feeds, keep them secret.</p>
+<ul>
+{{range .Feeds}}<li>{{.Title}}: <a href="{{.URL}}">{{.URL}}</a></li>
+{{end}}</ul>
+<form method="post" action="{{.Base}}/rotate"><button>Replace the links</button> the current ones stop working.</form>
+<form method="post" action="{{.Base}}/revoke"><button>Revoke</button> remove your feeds and the authorization given to this instance.</form>
+</body>
+</html>
+`))
+
+type privateFeedLink struct {
+	Title string
+	URL   string
+}
+
+var privateFeedLinks = []struct{ kind, title string }{
+	{"front", "Front page"},
+	{"saved", "Saved"},
+	{"upvoted", "Upvoted"},
+	{"inbox", "Inbox"},
+	{"unread", "Unread messages"},
+}
+
+// privateFeeds serves the feeds of Reddit users who authorized them, found by their feed token.
+type privateFeeds struct {
+	users      *auth.UserFlow
+	registry   *auth.Registry
+	publicURL  string
+	httpClient *http.Client
+	userAgent  string
+	seen       *store.Store
+}
+
+func (p *privateFeeds) login(w http.ResponseWriter, r *http.Request) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	state := hex.EncodeToString(b)
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     stateCookie,
+		Value:    state,
+		Path:     "/auth/callback",
+		MaxAge:   600,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+	http.Redirect(w, r, p.users.AuthURL(state), http.StatusFound)
+}
+
+func (p *privateFeeds) callback(w http.ResponseWriter, r *http.Request) {
+	var expectedState string
+	if cookie, err := r.Cookie(stateCookie); err == nil {
+		expectedState = cookie.Value
+	}
+	http.SetCookie(w, &http.Cookie{Name: stateCookie, Path: "/auth/callback", MaxAge: -1})
+
+	q := r.URL.Query()
+	user, err := p.users.Exchange(r.Context(), expectedState, q.Get("state"), q.Get("code"))
+	if err != nil {
+		log.Printf("ERROR: Unable to authorize private feeds: %s", err.Error())
+		http.Error(w, err.Error()+"\nUnable to authorize, try again from /feeds/login.", http.StatusBadRequest)
+		return
+	}
+
+	log.Printf("Private feeds authorized by %s", user.Name)
+	p.showFeeds(w, user)
+}
+
+func (p *privateFeeds) feed(w http.ResponseWriter, r *http.Request) {
+	user, err := p.registry.Lookup(r.PathValue("token"))
+	if errors.Is(err, auth.ErrUnknownFeedToken) {
+		http.NotFound(w, r)
+		return
+	}
+	if err != nil {
+		log.Printf("ERROR: Unable to look up feed token: %s", err.Error())
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	token, err := p.users.Token(r.Context(), user)
+	if err != nil {
+		log.Printf("ERROR: Unable to login as %s: %s", user.Name, err.Error())
+		http.Error(w, err.Error()+"\nUnable to login, authorize again from /feeds/login.", http.StatusBadGateway)
+		return
+	}
+
+	redditClient := &client.RedditClient{
+		HttpClient: p.httpClient,
+		Token:      token,
+		UserAgent:  p.userAgent,
+	}
+	client.PrivateHandler("https://oauth.reddit.com", time.Now, redditClient, client.GetArticle, p.seen, user.Name, w, r)
+}
+
+func (p *privateFeeds) rotate(w http.ResponseWriter, r *http.Request) {
+	user, err := p.registry.Rotate(r.PathValue("token"))
+	if errors.Is(err, auth.ErrUnknownFeedToken) {
+		http.NotFound(w, r)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	log.Printf("Private feeds of %s moved to a new token", user.Name)
+	p.showFeeds(w, user)
+}
+
+func (p *privateFeeds) revoke(w http.ResponseWriter, r *http.Request) {
+	user, err := p.users.Revoke(r.Context(), r.PathValue("token"))
+	if errors.Is(err, auth.ErrUnknownFeedToken) {
+		http.NotFound(w, r)
+		return
+	}
+	if err != nil && user.Name == "" {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if err != nil {
+		// the feeds are gone either way
+		log.Printf("WARN: Unable to revoke the authorization of %s on Reddit: %s", user.Name, err.Error())
+	}
+
+	log.Printf("Private feeds of %s revoked", user.Name)
+	w.Write([]byte("Your private feeds are revoked."))
+}
+
+func (p *privateFeeds) showFeeds(w http.ResponseWriter, user auth.User) {
+	base := p.publicURL + "/feeds/" + user.FeedToken
+
+	var links []privateFeedLink
+	for _, f := range privateFeedLinks {
+		links = append(links, privateFeedLink{Title: f.title, URL: base + "/" + f.kind})
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	err := privateFeedsPage.Execute(w, struct {
+		Name  string
+		Base  string
+		Feeds []privateFeedLink
+	}{user.Name, base, links})
+	if err != nil {
+		log.Printf("ERROR: %s", err.Error())
+	}
+}
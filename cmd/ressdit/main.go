@@ -1,20 +1,25 @@
 package main
 
 import (
+	"context"
+	"crypto/subtle"
 	"crypto/tls"
 	"fmt"
 	"log"
 	"net/http"
 	"net/url"
 	"os"
+	"strconv"
 	"time"
 
 	"github.com/getsentry/sentry-go"
 	sentryhttp "github.com/getsentry/sentry-go/http"
 	"github.com/joho/godotenv"
+	"github.com/sorae42/ressdit/pkg/auth"
+	"github.com/sorae42/ressdit/pkg/cache"
 	"github.com/sorae42/ressdit/pkg/client"
-	cache "github.com/victorspringer/http-cache"
-	"github.com/victorspringer/http-cache/adapter/redis"
+	"github.com/sorae42/ressdit/pkg/store"
+	httpcache "github.com/victorspringer/http-cache"
 	"golang.org/x/oauth2"
 )
 
@@ -32,14 +37,64 @@ func main() {
 	password := os.Getenv("REDDIT_PASSWORD")
 	oauthClientID := os.Getenv("OAUTH_CLIENT_ID")
 	oauthClientSecret := os.Getenv("OAUTH_CLIENT_SECRET")
+	tokenFilePath := os.Getenv("TOKEN_FILE_PATH")
 
-	if username != "" && password != "" {
-		if oauthClientID == "" || oauthClientSecret == "" {
-			log.Println("WARN: Login credentials provided without oauth client. This will be ignored.")
-		} else {
-			log.Printf("Logging in as %s", username)
+	userAgent := os.Getenv("USER_AGENT")
+	if userAgent == "" {
+		userAgent = "Ressdit " + VERSION
+	}
+
+	if username != "" && password != "" && (oauthClientID == "" || oauthClientSecret == "") {
+		log.Println("WARN: Login credentials provided without oauth client. This will be ignored.")
+	}
+
+	// login as the account when its credentials or an authorization are given, as the application otherwise
+	oauthGrant := os.Getenv("OAUTH_GRANT")
+	if oauthGrant == "" && oauthClientID != "" {
+		switch {
+		case username != "" && password != "" && oauthClientSecret != "":
+			oauthGrant = auth.GrantPassword
+		case tokenFilePath != "":
+			oauthGrant = auth.GrantAuthorizationCode
+		case oauthClientSecret != "":
+			oauthGrant = auth.GrantClientCredentials
+		default:
+			oauthGrant = auth.GrantInstalledClient
 		}
 	}
+	if oauthGrant != "" && oauthClientID == "" {
+		log.Fatalf("OAUTH_GRANT %s requires OAUTH_CLIENT_ID", oauthGrant)
+	}
+
+	var login auth.LoginFunc
+	var codeFlow *auth.CodeFlow
+	switch oauthGrant {
+	case "":
+	case auth.GrantPassword:
+		log.Printf("Logging in as %s", username)
+		login = auth.PasswordLogin(oauthClientID, oauthClientSecret, username, password)
+	case auth.GrantClientCredentials:
+		log.Println("Logging in as application")
+		login = auth.ClientCredentialsLogin(oauthClientID, oauthClientSecret)
+	case auth.GrantInstalledClient:
+		deviceID := os.Getenv("OAUTH_DEVICE_ID")
+		if deviceID == "" {
+			deviceID = auth.DefaultDeviceID
+		}
+		log.Println("Logging in as installed application")
+		login = auth.InstalledClientLogin(oauthClientID, deviceID)
+	case auth.GrantAuthorizationCode:
+		redirectURL := os.Getenv("OAUTH_REDIRECT_URL")
+		tokenFileKey := os.Getenv("TOKEN_FILE_KEY")
+		if tokenFilePath == "" || tokenFileKey == "" || redirectURL == "" || os.Getenv("ADMIN_PASSWORD") == "" {
+			log.Fatal("OAUTH_GRANT authorization_code requires TOKEN_FILE_PATH, TOKEN_FILE_KEY, OAUTH_REDIRECT_URL and ADMIN_PASSWORD")
+		}
+		codeFlow = auth.NewCodeFlow(oauthClientID, oauthClientSecret, redirectURL, userAgent, auth.NewTokenFile(tokenFilePath, tokenFileKey), time.Now)
+		log.Println("Logging in with the authorization stored in", tokenFilePath)
+		login = codeFlow.Login
+	default:
+		log.Fatalf("Unknown OAUTH_GRANT %s, expected password, client_credentials, installed_client or authorization_code", oauthGrant)
+	}
 
 	http.DefaultTransport.(*http.Transport).TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
 
@@ -53,27 +108,161 @@ func main() {
 
 	sentryHandler := sentryhttp.New(sentryhttp.Options{})
 
+	var seen *store.Store
+	seenStorePath := os.Getenv("SEEN_STORE_PATH")
+	if seenStorePath != "" {
+		retention := 30 * 24 * time.Hour
+		if r := os.Getenv("SEEN_RETENTION"); r != "" {
+			retention, err = time.ParseDuration(r)
+			if err != nil {
+				log.Fatal(err)
+			}
+		}
+
+		seen, err = store.Open(seenStorePath, retention)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer seen.Close()
+
+		go seen.Maintain(24*time.Hour, time.Now, log.Printf, nil)
+		log.Printf("Tracking seen items in %s", seenStorePath)
+	}
+
+	var tokens *auth.TokenManager
+	if login != nil {
+		// the token is shared by every request
+		tokens = auth.NewTokenManager(login, time.Now)
+
+		go func() {
+			if _, err := tokens.Token(context.Background()); err != nil {
+				log.Printf("ERROR: Unable to login: %s", err.Error())
+			}
+		}()
+	}
+
 	http.HandleFunc("/info/ping", sentryHandler.HandleFunc(func(w http.ResponseWriter, r *http.Request) {
+		if tokens != nil {
+			if status := tokens.Status(); !status.Healthy() {
+				http.Error(w, fmt.Sprintf("Unable to login since %s: %s", status.LastErrorAt.Format(time.RFC3339), status.LastError.Error()), http.StatusServiceUnavailable)
+				return
+			}
+		}
 		w.Write([]byte("OK"))
 	}))
 
+	// every request shares the rate limit budget reddit gives us
+	rateLimitMaxWait := 10 * time.Second
+	if d := os.Getenv("RATELIMIT_MAX_WAIT"); d != "" {
+		rateLimitMaxWait, err = time.ParseDuration(d)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+	limiter := client.NewRateLimiter(client.NewTransport(), rateLimitMaxWait, time.Now)
+	httpClient := &http.Client{
+		Transport: limiter,
+	}
+
+	var private *privateFeeds
+	registryPath := os.Getenv("USER_REGISTRY_PATH")
+	if registryPath != "" {
+		redirectURL := os.Getenv("OAUTH_REDIRECT_URL")
+		tokenFileKey := os.Getenv("TOKEN_FILE_KEY")
+		if oauthClientID == "" || oauthClientSecret == "" || redirectURL == "" || tokenFileKey == "" {
+			log.Fatal("USER_REGISTRY_PATH requires OAUTH_CLIENT_ID, OAUTH_CLIENT_SECRET, OAUTH_REDIRECT_URL and TOKEN_FILE_KEY")
+		}
+		publicURL, err := url.Parse(redirectURL)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		registry, err := auth.OpenRegistry(registryPath, tokenFileKey)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer registry.Close()
+
+		private = &privateFeeds{
+			users:      auth.NewUserFlow(oauthClientID, oauthClientSecret, redirectURL, userAgent, registry, time.Now),
+			registry:   registry,
+			publicURL:  fmt.Sprintf("%s://%s", publicURL.Scheme, publicURL.Host),
+			httpClient: httpClient,
+			userAgent:  userAgent,
+			seen:       seen,
+		}
+		http.HandleFunc("GET /feeds/login", private.login)
+		http.HandleFunc("GET /feeds/{token}/{kind}", private.feed)
+		http.HandleFunc("POST /feeds/{token}/rotate", private.rotate)
+		http.HandleFunc("POST /feeds/{token}/revoke", private.revoke)
+		log.Printf("Serving private feeds of the users in %s", registryPath)
+	}
+
+	if codeFlow != nil {
+		http.HandleFunc("/auth/login", requireAdmin(os.Getenv("ADMIN_PASSWORD"), func(w http.ResponseWriter, r *http.Request) {
+			authURL, err := codeFlow.AuthURL()
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			http.Redirect(w, r, authURL, http.StatusFound)
+		}))
+	}
+
+	if codeFlow != nil || private != nil {
+		http.HandleFunc("/auth/callback", func(w http.ResponseWriter, r *http.Request) {
+			q := r.URL.Query()
+			if reason := q.Get("error"); reason != "" {
+				http.Error(w, "Authorization denied: "+reason, http.StatusForbidden)
+				return
+			}
+
+			// the app has a single redirect uri, users authorizing their private feeds come back here too
+			if private != nil && (codeFlow == nil || !codeFlow.Owns(q.Get("state"))) {
+				private.callback(w, r)
+				return
+			}
+
+			token, err := codeFlow.Exchange(r.Context(), q.Get("state"), q.Get("code"))
+			if err != nil {
+				log.Printf("ERROR: Unable to authorize: %s", err.Error())
+				http.Error(w, err.Error()+"\nUnable to authorize, try again from /auth/login.", http.StatusBadRequest)
+				return
+			}
+			tokens.Set(token)
+
+			log.Println("Authorized, feeds are fetched with the new authorization")
+			w.Write([]byte("Authorized, feeds are now fetched with your account."))
+		})
+	}
+
+	feedCache, err := newCache()
+	if err != nil {
+		log.Fatal(err)
+	}
+	// rendered feeds, the listings they are made of and the articles of their posts each have their own lifetime
+	cacheTTL := durationEnv("CACHE_TTL", 60*time.Minute)
+	listingTTL := durationEnv("LISTING_CACHE_TTL", 2*time.Minute)
+	refreshWorkers := 2
+	if s := os.Getenv("REFRESH_WORKERS"); s != "" {
+		refreshWorkers, err = strconv.Atoi(s)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+	getArticle := client.GetArticle
+	if feedCache != nil {
+		getArticle = client.CachedArticles(feedCache, durationEnv("ARTICLE_CACHE_TTL", 24*time.Hour), client.GetArticle)
+	}
+
 	var rssHandler http.Handler
 	rssHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		httpClient := http.DefaultClient
 		var token *oauth2.Token
 		baseApiUrl := "https://www.reddit.com"
 
-		if oauthClientID != "" {
-			oauthCfg := &oauth2.Config{
-				ClientID:     oauthClientID,
-				ClientSecret: oauthClientSecret,
-				Endpoint: oauth2.Endpoint{
-					TokenURL:  "https://www.reddit.com/api/v1/access_token",
-					AuthStyle: oauth2.AuthStyleInHeader,
-				},
-			}
-			// login with reddit user password
-			token, err = oauthCfg.PasswordCredentialsToken(r.Context(), username, password)
+		if tokens != nil {
+			var err error
+			token, err = tokens.Token(r.Context())
 			if err != nil {
 				log.Println("ERROR: Unable to login. Check your credentials.")
 				http.Error(w, err.Error()+"\nUnable to login. Check your credentials.", 500)
@@ -82,50 +271,52 @@ func main() {
 			baseApiUrl = "https://oauth.reddit.com"
 		}
 
-		userAgent := os.Getenv("USER_AGENT")
-		if userAgent == "" {
-			userAgent = "Ressdit " + VERSION
-		}
 		redditClient := &client.RedditClient{
 			HttpClient: httpClient,
 			Token:      token,
 			UserAgent:  userAgent,
+			Cache:      feedCache,
+			ListingTTL: listingTTL,
 		}
 
-		if r.URL.String() == "/" {
+		if r.URL.Path == "/" {
 			http.Redirect(w, r, "https://github.com/sorae42/ressdit/blob/main/README.md", http.StatusMovedPermanently)
 			return
 		}
 
-		if r.URL.String() == "/favicon.ico" {
+		if r.URL.Path == "/favicon.ico" {
 			return
 		}
 
-		client.RssHandler(baseApiUrl, time.Now, redditClient, client.GetArticle, w, r)
+		client.RssHandler(baseApiUrl, time.Now, redditClient, getArticle, seen, w, r)
 	})
 
-	redisCacheUrl := os.Getenv("FLY_REDIS_CACHE_URL")
-	if redisCacheUrl != "" {
-		u, err := url.Parse(redisCacheUrl)
-		if err != nil {
-			log.Fatal(err)
-		}
-		pass, _ := u.User.Password()
-		ringOpt := &redis.RingOptions{
-			Addrs: map[string]string{
-				"server": u.Host,
-			},
-			Password: pass,
-		}
-		cacheClient, err := cache.NewClient(
-			cache.ClientWithAdapter(redis.NewAdapter(ringOpt)),
-			cache.ClientWithTTL(60*time.Minute),
-			cache.ClientWithRefreshKey("opn"),
+	// the messages of the account are for its owner only, and never cached
+	if adminPassword := os.Getenv("ADMIN_PASSWORD"); adminPassword != "" {
+		http.Handle("/message/", requireAdmin(adminPassword, rssHandler.ServeHTTP))
+	} else {
+		http.HandleFunc("/message/", func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "Message feeds need ADMIN_PASSWORD to be set.", http.StatusForbidden)
+		})
+	}
+
+	if feedCache != nil {
+		cacheClient, err := httpcache.NewClient(
+			httpcache.ClientWithAdapter(cache.Adapter(feedCache)),
+			httpcache.ClientWithTTL(cacheTTL),
+			httpcache.ClientWithRefreshKey(client.RefreshParam),
 		)
 		if err != nil {
 			log.Fatal(err)
 		}
-		rssHandler = cacheClient.Middleware(rssHandler)
+		refresher := client.NewRefresher(feedCache, cacheTTL, limiter, refreshWorkers, time.Now)
+		rssHandler = client.NormalizeFeedURL(refresher.Track(refresher.ServeStale(cacheClient.Middleware(cache.OnlyOK(refresher.Render(rssHandler))))))
+		if refreshWorkers > 0 {
+			go refresher.Run(rssHandler, time.Minute, nil)
+		}
+
+		// the cache replays whole responses, conditional requests are answered in front of it
+		rssHandler = client.ConditionalGET(rssHandler)
 	}
 
 	http.Handle("/", rssHandler)
@@ -138,3 +329,16 @@ func main() {
 
 	log.Fatal(http.ListenAndServe(fmt.Sprintf(":%s", port), nil))
 }
+
+// requireAdmin asks for the ADMIN_PASSWORD with basic auth before calling next, any user name goes.
+func requireAdmin(password string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		_, given, ok := r.BasicAuth()
+		if !ok || subtle.ConstantTimeCompare([]byte(given), []byte(password)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Basic realm="ressdit"`)
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next(w, r)
+	}
+}
@@ -0,0 +1,163 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/cameronstanley/go-reddit"
+	"golang.org/x/oauth2"
+)
+
+// ErrNotAuthorized is returned by CodeFlow.Login until an operator authorized the app in a browser.
+var ErrNotAuthorized = errors.New("not authorized yet, visit /auth/login")
+
+// CodeFlowScopes are asked for when authorizing in a browser.
+var CodeFlowScopes = []string{
+	reddit.ScopeIdentity,
+	reddit.ScopeRead,
+	reddit.ScopeHistory,
+	reddit.ScopeMySubreddits,
+	reddit.ScopePrivateMessages,
+}
+
+// stateTTL is how long the operator has to authorize the app once sent to Reddit.
+const stateTTL = 10 * time.Minute
+
+// CodeFlow runs the authorization code flow once in a browser, then logs in with the refresh token it yields.
+type CodeFlow struct {
+	clientID     string
+	clientSecret string
+	redirectURL  string
+	userAgent    string
+	file         *TokenFile
+	now          func() time.Time
+
+	mu           sync.Mutex
+	refreshToken string
+	// states are the pending authorizations, by the expiry of their state
+	states map[string]time.Time
+}
+
+// NewCodeFlow prepares the flow of the app clientID, which redirects to redirectURL once authorized.
+// The refresh token is kept in file.
+func NewCodeFlow(clientID, clientSecret, redirectURL, userAgent string, file *TokenFile, now func() time.Time) *CodeFlow {
+	return &CodeFlow{
+		clientID:     clientID,
+		clientSecret: clientSecret,
+		redirectURL:  redirectURL,
+		userAgent:    userAgent,
+		file:         file,
+		now:          now,
+		states:       make(map[string]time.Time),
+	}
+}
+
+func (f *CodeFlow) authenticator(state string) *reddit.Authenticator {
+	authenticator := reddit.NewAuthenticator(f.clientID, f.clientSecret, f.redirectURL, f.userAgent, state, CodeFlowScopes...)
+	authenticator.RequestPermanentToken = true
+	return authenticator
+}
+
+// AuthURL is the Reddit page asking the operator to authorize the app. Each page has its own state,
+// which Reddit redirects back with and which is valid once, for stateTTL.
+func (f *CodeFlow) AuthURL() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	state := hex.EncodeToString(b)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	now := f.now()
+	for pending, expiry := range f.states {
+		if !now.Before(expiry) {
+			delete(f.states, pending)
+		}
+	}
+	f.states[state] = now.Add(stateTTL)
+
+	return f.authenticator(state).GetAuthenticationURL(), nil
+}
+
+// Owns reports whether Reddit redirected back with state from a page at AuthURL that is still pending.
+func (f *CodeFlow) Owns(state string) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	expiry, ok := f.states[state]
+	return ok && f.now().Before(expiry)
+}
+
+// consume forgets state, and reports whether it was pending.
+func (f *CodeFlow) consume(state string) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	expiry, ok := f.states[state]
+	delete(f.states, state)
+	return ok && f.now().Before(expiry)
+}
+
+// Exchange trades the code Reddit redirected back with for a token, and stores its refresh token.
+func (f *CodeFlow) Exchange(ctx context.Context, state string, code string) (*oauth2.Token, error) {
+	if !f.consume(state) {
+		return nil, errors.New("invalid state")
+	}
+
+	token, err := f.authenticator(state).GetToken(ctx, state, code)
+	if err != nil {
+		return nil, err
+	}
+	if token.RefreshToken == "" {
+		return nil, errors.New("no refresh token was granted")
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.file.Save(token.RefreshToken); err != nil {
+		return nil, err
+	}
+	f.refreshToken = token.RefreshToken
+
+	return token, nil
+}
+
+// Login is a LoginFunc getting a new access token with the stored refresh token.
+func (f *CodeFlow) Login(ctx context.Context) (*oauth2.Token, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.refreshToken == "" {
+		refreshToken, err := f.file.Load()
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, ErrNotAuthorized
+		}
+		if err != nil {
+			return nil, err
+		}
+		f.refreshToken = refreshToken
+	}
+
+	token, err := f.authenticator("").RefreshToken(ctx, f.refreshToken)
+	if err != nil {
+		return nil, err
+	}
+
+	// reddit may hand out a new refresh token
+	if token.RefreshToken != "" && token.RefreshToken != f.refreshToken {
+		if err := f.file.Save(token.RefreshToken); err != nil {
+			return nil, err
+		}
+		f.refreshToken = token.RefreshToken
+	}
+
+	return token, nil
+}
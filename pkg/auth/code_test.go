@@ -0,0 +1,97 @@
+package auth
+
+import (
+	"context"
+	"net/url"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestCodeFlow(t *testing.T) (*CodeFlow, *TokenFile, *time.Time) {
+	now := time.Now()
+	file := NewTokenFile(filepath.Join(t.TempDir(), "token"), "passphrase")
+	f := NewCodeFlow("id", "secret", "http://localhost/auth/callback", "test", file, func() time.Time { return now })
+	return f, file, &now
+}
+
+// authState returns the state of the authorization page at authURL.
+func authState(t *testing.T, authURL string) string {
+	u, err := url.Parse(authURL)
+	require.NoError(t, err)
+	state := u.Query().Get("state")
+	require.NotEmpty(t, state)
+	return state
+}
+
+func TestCodeFlowState(t *testing.T) {
+	f, _, now := newTestCodeFlow(t)
+
+	first, err := f.AuthURL()
+	require.NoError(t, err)
+	second, err := f.AuthURL()
+	require.NoError(t, err)
+
+	// every login gets its own state
+	a, b := authState(t, first), authState(t, second)
+	assert.NotEqual(t, a, b)
+	assert.True(t, f.Owns(a))
+	assert.True(t, f.Owns(b))
+	assert.False(t, f.Owns("forged"))
+	assert.False(t, f.Owns(""))
+
+	*now = now.Add(stateTTL)
+	assert.False(t, f.Owns(a))
+}
+
+func TestCodeFlowExchange(t *testing.T) {
+	f, file, now := newTestCodeFlow(t)
+	fakeTokenEndpoint(t, "refresh1")
+
+	authURL, err := f.AuthURL()
+	require.NoError(t, err)
+	state := authState(t, authURL)
+
+	_, err = f.Exchange(context.Background(), "forged", "code")
+	assert.EqualError(t, err, "invalid state")
+
+	token, err := f.Exchange(context.Background(), state, "code")
+	require.NoError(t, err)
+	assert.Equal(t, "access", token.AccessToken)
+	refreshToken, err := file.Load()
+	require.NoError(t, err)
+	assert.Equal(t, "refresh1", refreshToken)
+
+	// a state is only good once
+	assert.False(t, f.Owns(state))
+	_, err = f.Exchange(context.Background(), state, "code")
+	assert.EqualError(t, err, "invalid state")
+
+	// and only for a while
+	authURL, err = f.AuthURL()
+	require.NoError(t, err)
+	*now = now.Add(stateTTL + time.Second)
+	_, err = f.Exchange(context.Background(), authState(t, authURL), "code")
+	assert.EqualError(t, err, "invalid state")
+}
+
+func TestCodeFlowLogin(t *testing.T) {
+	f, file, _ := newTestCodeFlow(t)
+
+	_, err := f.Login(context.Background())
+	assert.ErrorIs(t, err, ErrNotAuthorized)
+
+	require.NoError(t, file.Save("refresh1"))
+	fakeTokenEndpoint(t, "refresh2")
+	token, err := f.Login(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "access", token.AccessToken)
+
+	// the new refresh token Reddit handed out is kept
+	refreshToken, err := file.Load()
+	require.NoError(t, err)
+	assert.Equal(t, "refresh2", refreshToken)
+}
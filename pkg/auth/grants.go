@@ -0,0 +1,69 @@
+package auth
+
+import (
+	"context"
+	"net/url"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/clientcredentials"
+)
+
+// TokenURL is Reddit's OAuth token endpoint.
+const TokenURL = "https://www.reddit.com/api/v1/access_token"
+
+// Grants a TokenManager can log in with.
+const (
+	GrantPassword          = "password"
+	GrantClientCredentials = "client_credentials"
+	GrantInstalledClient   = "installed_client"
+	GrantAuthorizationCode = "authorization_code"
+)
+
+const installedClientGrantType = "https://oauth.reddit.com/grants/installed_client"
+
+// DefaultDeviceID asks Reddit not to track the device of an installed client.
+const DefaultDeviceID = "DO_NOT_TRACK_THIS_DEVICE"
+
+// PasswordLogin logs in as the Reddit account that owns the "script" app clientID.
+func PasswordLogin(clientID, clientSecret, username, password string) LoginFunc {
+	cfg := &oauth2.Config{
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		Endpoint: oauth2.Endpoint{
+			TokenURL:  TokenURL,
+			AuthStyle: oauth2.AuthStyleInHeader,
+		},
+	}
+
+	return func(ctx context.Context) (*oauth2.Token, error) {
+		return cfg.PasswordCredentialsToken(ctx, username, password)
+	}
+}
+
+// ClientCredentialsLogin gets an application only token for a confidential app, without a Reddit account.
+func ClientCredentialsLogin(clientID, clientSecret string) LoginFunc {
+	cfg := &clientcredentials.Config{
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		TokenURL:     TokenURL,
+		AuthStyle:    oauth2.AuthStyleInHeader,
+	}
+
+	return cfg.Token
+}
+
+// InstalledClientLogin gets an application only token for an "installed app", which has no secret.
+// deviceID must be unique per instance, or DefaultDeviceID.
+func InstalledClientLogin(clientID, deviceID string) LoginFunc {
+	cfg := &clientcredentials.Config{
+		ClientID:  clientID,
+		TokenURL:  TokenURL,
+		AuthStyle: oauth2.AuthStyleInHeader,
+		EndpointParams: url.Values{
+			"grant_type": {installedClientGrantType},
+			"device_id":  {deviceID},
+		},
+	}
+
+	return cfg.Token
+}
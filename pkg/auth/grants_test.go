@@ -0,0 +1,80 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/oauth2"
+)
+
+// tokenRequest is what a login sent to the token endpoint.
+type tokenRequest struct {
+	url      string
+	form     url.Values
+	username string
+	password string
+	basic    bool
+}
+
+// recordLogin logs in with login against a fake token endpoint and returns the request it sent.
+func recordLogin(t *testing.T, login LoginFunc) tokenRequest {
+	var sent tokenRequest
+	client := &http.Client{Transport: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		require.NoError(t, req.ParseForm())
+		sent.url = req.URL.String()
+		sent.form = req.PostForm
+		sent.username, sent.password, sent.basic = req.BasicAuth()
+
+		w := httptest.NewRecorder()
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteString(`{"access_token": "access", "token_type": "bearer", "expires_in": 3600}`)
+		return w.Result(), nil
+	})}
+
+	token, err := login(context.WithValue(context.Background(), oauth2.HTTPClient, client))
+	require.NoError(t, err)
+	assert.Equal(t, "access", token.AccessToken)
+	return sent
+}
+
+func TestInstalledClientLogin(t *testing.T) {
+	sent := recordLogin(t, InstalledClientLogin("id", "device-1234567890123456"))
+
+	assert.Equal(t, TokenURL, sent.url)
+	assert.Equal(t, "https://oauth.reddit.com/grants/installed_client", sent.form.Get("grant_type"))
+	assert.Equal(t, "device-1234567890123456", sent.form.Get("device_id"))
+	assert.Len(t, sent.form["grant_type"], 1)
+
+	// the app has no secret
+	assert.True(t, sent.basic)
+	assert.Equal(t, "id", sent.username)
+	assert.Empty(t, sent.password)
+}
+
+func TestClientCredentialsLogin(t *testing.T) {
+	sent := recordLogin(t, ClientCredentialsLogin("id", "secret"))
+
+	assert.Equal(t, TokenURL, sent.url)
+	assert.Equal(t, "client_credentials", sent.form.Get("grant_type"))
+	assert.True(t, sent.basic)
+	assert.Equal(t, "id", sent.username)
+	assert.Equal(t, "secret", sent.password)
+	assert.Empty(t, sent.form.Get("client_id"))
+	assert.Empty(t, sent.form.Get("client_secret"))
+}
+
+func TestPasswordLogin(t *testing.T) {
+	sent := recordLogin(t, PasswordLogin("id", "secret", "alice", "hunter2"))
+
+	assert.Equal(t, "password", sent.form.Get("grant_type"))
+	assert.Equal(t, "alice", sent.form.Get("username"))
+	assert.Equal(t, "hunter2", sent.form.Get("password"))
+	assert.True(t, sent.basic)
+	assert.Equal(t, "secret", sent.password)
+	assert.Empty(t, sent.form.Get("client_secret"))
+}
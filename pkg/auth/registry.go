@@ -0,0 +1,238 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+var (
+	usersBucket  = []byte("users")
+	tokensBucket = []byte("tokens")
+)
+
+var (
+	// ErrUnknownFeedToken is returned for feed tokens that were never issued, rotated or revoked.
+	ErrUnknownFeedToken = errors.New("unknown feed token")
+	// ErrUnknownUser is returned for users that never registered or revoked their feeds.
+	ErrUnknownUser = errors.New("unknown user")
+)
+
+// User is a Reddit account that authorized its private feeds.
+type User struct {
+	Name         string    `json:"name"`
+	RefreshToken string    `json:"refresh_token"`
+	FeedToken    string    `json:"feed_token"`
+	Created      time.Time `json:"created"`
+}
+
+// Registry maps the secret feed tokens of users to their Reddit authorization, in an embedded BoltDB file.
+// Users are stored encrypted, tokens are indexed by their hash only.
+type Registry struct {
+	db  *bolt.DB
+	key [32]byte
+}
+
+// OpenRegistry opens or creates the registry at path, encrypted with a key derived from passphrase.
+func OpenRegistry(path string, passphrase string) (*Registry, error) {
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		if _, err := tx.CreateBucketIfNotExists(usersBucket); err != nil {
+			return err
+		}
+		_, err := tx.CreateBucketIfNotExists(tokensBucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &Registry{
+		db:  db,
+		key: sealKey(passphrase),
+	}, nil
+}
+
+// Close closes the underlying database file.
+func (r *Registry) Close() error {
+	return r.db.Close()
+}
+
+// Register stores the authorization of the user name. A new user gets a feed token,
+// a returning one keeps theirs.
+func (r *Registry) Register(name string, refreshToken string, now time.Time) (User, error) {
+	var user User
+	err := r.db.Update(func(tx *bolt.Tx) error {
+		existing, err := r.get(tx, name)
+		if err != nil && !errors.Is(err, ErrUnknownUser) {
+			return err
+		}
+
+		user = existing
+		user.Name = name
+		user.RefreshToken = refreshToken
+		if user.FeedToken == "" {
+			user.Created = now
+			if user.FeedToken, err = r.issue(tx, name); err != nil {
+				return err
+			}
+		}
+
+		return r.put(tx, user)
+	})
+
+	return user, err
+}
+
+// SetRefreshToken replaces the stored refresh token of the user name, when Reddit handed out a new one.
+func (r *Registry) SetRefreshToken(name string, refreshToken string) error {
+	return r.db.Update(func(tx *bolt.Tx) error {
+		user, err := r.get(tx, name)
+		if err != nil {
+			return err
+		}
+
+		user.RefreshToken = refreshToken
+		return r.put(tx, user)
+	})
+}
+
+// Lookup returns the user a feed token was issued to.
+func (r *Registry) Lookup(feedToken string) (User, error) {
+	var user User
+	err := r.db.View(func(tx *bolt.Tx) error {
+		var err error
+		user, err = r.lookup(tx, feedToken)
+		return err
+	})
+
+	return user, err
+}
+
+// User returns the registered user name.
+func (r *Registry) User(name string) (User, error) {
+	var user User
+	err := r.db.View(func(tx *bolt.Tx) error {
+		var err error
+		user, err = r.get(tx, name)
+		return err
+	})
+
+	return user, err
+}
+
+// Rotate replaces a feed token with a new one, feeds using the old one stop working.
+func (r *Registry) Rotate(feedToken string) (User, error) {
+	var user User
+	err := r.db.Update(func(tx *bolt.Tx) error {
+		var err error
+		user, err = r.lookup(tx, feedToken)
+		if err != nil {
+			return err
+		}
+
+		if err := tx.Bucket(tokensBucket).Delete(hashFeedToken(feedToken)); err != nil {
+			return err
+		}
+		if user.FeedToken, err = r.issue(tx, user.Name); err != nil {
+			return err
+		}
+
+		return r.put(tx, user)
+	})
+
+	return user, err
+}
+
+// Revoke forgets the user a feed token was issued to, along with their authorization.
+// The returned user still holds the refresh token, so it can be revoked on Reddit too.
+func (r *Registry) Revoke(feedToken string) (User, error) {
+	var user User
+	err := r.db.Update(func(tx *bolt.Tx) error {
+		var err error
+		user, err = r.lookup(tx, feedToken)
+		if err != nil {
+			return err
+		}
+
+		if err := tx.Bucket(tokensBucket).Delete(hashFeedToken(feedToken)); err != nil {
+			return err
+		}
+		return tx.Bucket(usersBucket).Delete(userKey(user.Name))
+	})
+
+	return user, err
+}
+
+func (r *Registry) lookup(tx *bolt.Tx, feedToken string) (User, error) {
+	name := tx.Bucket(tokensBucket).Get(hashFeedToken(feedToken))
+	if name == nil {
+		return User{}, ErrUnknownFeedToken
+	}
+
+	return r.get(tx, string(name))
+}
+
+func (r *Registry) get(tx *bolt.Tx, name string) (User, error) {
+	sealed := tx.Bucket(usersBucket).Get(userKey(name))
+	if sealed == nil {
+		return User{}, ErrUnknownUser
+	}
+
+	plaintext, err := unseal(r.key, sealed)
+	if err != nil {
+		return User{}, err
+	}
+
+	var user User
+	err = json.Unmarshal(plaintext, &user)
+	return user, err
+}
+
+func (r *Registry) put(tx *bolt.Tx, user User) error {
+	plaintext, err := json.Marshal(user)
+	if err != nil {
+		return err
+	}
+
+	sealed, err := seal(r.key, plaintext)
+	if err != nil {
+		return err
+	}
+
+	return tx.Bucket(usersBucket).Put(userKey(user.Name), sealed)
+}
+
+// issue creates a feed token for the user name.
+func (r *Registry) issue(tx *bolt.Tx, name string) (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	feedToken := base64.RawURLEncoding.EncodeToString(b)
+
+	err := tx.Bucket(tokensBucket).Put(hashFeedToken(feedToken), userKey(name))
+	return feedToken, err
+}
+
+// userKey is case insensitive, like Reddit user names.
+func userKey(name string) []byte {
+	return []byte(strings.ToLower(name))
+}
+
+func hashFeedToken(feedToken string) []byte {
+	sum := sha256.Sum256([]byte(feedToken))
+	return []byte(hex.EncodeToString(sum[:]))
+}
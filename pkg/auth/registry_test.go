@@ -0,0 +1,95 @@
+package auth
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func openTestRegistry(t *testing.T) *Registry {
+	r, err := OpenRegistry(filepath.Join(t.TempDir(), "users.db"), "passphrase")
+	require.NoError(t, err)
+	t.Cleanup(func() { r.Close() })
+	return r
+}
+
+func TestRegister(t *testing.T) {
+	r := openTestRegistry(t)
+	now := time.Now().UTC()
+
+	user, err := r.Register("Alice", "refresh1", now)
+	require.NoError(t, err)
+	assert.NotEmpty(t, user.FeedToken)
+
+	// a returning user keeps their feed token, whatever the case of their name
+	again, err := r.Register("alice", "refresh2", now.Add(time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, user.FeedToken, again.FeedToken)
+	assert.True(t, now.Equal(again.Created))
+
+	found, err := r.Lookup(user.FeedToken)
+	require.NoError(t, err)
+	assert.Equal(t, "refresh2", found.RefreshToken)
+
+	_, err = r.Lookup("unknown")
+	assert.ErrorIs(t, err, ErrUnknownFeedToken)
+}
+
+func TestRotate(t *testing.T) {
+	r := openTestRegistry(t)
+	user, err := r.Register("alice", "refresh", time.Now())
+	require.NoError(t, err)
+
+	rotated, err := r.Rotate(user.FeedToken)
+	require.NoError(t, err)
+	assert.NotEqual(t, user.FeedToken, rotated.FeedToken)
+	assert.Equal(t, "refresh", rotated.RefreshToken)
+
+	_, err = r.Lookup(user.FeedToken)
+	assert.ErrorIs(t, err, ErrUnknownFeedToken)
+	found, err := r.Lookup(rotated.FeedToken)
+	require.NoError(t, err)
+	assert.Equal(t, rotated.FeedToken, found.FeedToken)
+
+	_, err = r.Rotate(user.FeedToken)
+	assert.ErrorIs(t, err, ErrUnknownFeedToken)
+}
+
+func TestRevoke(t *testing.T) {
+	r := openTestRegistry(t)
+	user, err := r.Register("alice", "refresh", time.Now())
+	require.NoError(t, err)
+
+	revoked, err := r.Revoke(user.FeedToken)
+	require.NoError(t, err)
+	assert.Equal(t, "refresh", revoked.RefreshToken)
+
+	_, err = r.Lookup(user.FeedToken)
+	assert.ErrorIs(t, err, ErrUnknownFeedToken)
+	_, err = r.User("alice")
+	assert.ErrorIs(t, err, ErrUnknownUser)
+	_, err = r.Revoke(user.FeedToken)
+	assert.ErrorIs(t, err, ErrUnknownFeedToken)
+
+	// registering again issues a new feed token
+	again, err := r.Register("alice", "refresh", time.Now())
+	require.NoError(t, err)
+	assert.NotEqual(t, user.FeedToken, again.FeedToken)
+}
+
+func TestSetRefreshToken(t *testing.T) {
+	r := openTestRegistry(t)
+	user, err := r.Register("alice", "refresh1", time.Now())
+	require.NoError(t, err)
+
+	require.NoError(t, r.SetRefreshToken("Alice", "refresh2"))
+	found, err := r.Lookup(user.FeedToken)
+	require.NoError(t, err)
+	assert.Equal(t, "refresh2", found.RefreshToken)
+	assert.Equal(t, user.FeedToken, found.FeedToken)
+
+	assert.ErrorIs(t, r.SetRefreshToken("bob", "refresh"), ErrUnknownUser)
+}
@@ -0,0 +1,143 @@
+// Package auth logs in to Reddit, as the account ressdit runs as or as the users of private feeds,
+// and keeps their OAuth tokens.
+package auth
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/sync/singleflight"
+)
+
+const (
+	// refreshBefore is how long before its expiry a token is replaced.
+	refreshBefore = 5 * time.Minute
+	// retryAfterFailure is how long a failed login is reported before trying again.
+	retryAfterFailure = 30 * time.Second
+	loginTimeout      = 30 * time.Second
+)
+
+// LoginFunc asks Reddit for a new token.
+type LoginFunc func(ctx context.Context) (*oauth2.Token, error)
+
+// Status describes the outcome of the latest logins, for health checks.
+type Status struct {
+	LastLogin   time.Time
+	Expiry      time.Time
+	LastError   error
+	LastErrorAt time.Time
+}
+
+// Healthy reports whether the latest login attempt succeeded.
+func (s Status) Healthy() bool {
+	return s.LastError == nil || s.LastLogin.After(s.LastErrorAt)
+}
+
+// TokenManager caches a token and logs in again shortly before it expires.
+// It is safe for concurrent use, concurrent requests for a new token share a single login.
+type TokenManager struct {
+	login LoginFunc
+	now   func() time.Time
+	group singleflight.Group
+
+	mu     sync.RWMutex
+	token  *oauth2.Token
+	status Status
+}
+
+func NewTokenManager(login LoginFunc, now func() time.Time) *TokenManager {
+	return &TokenManager{
+		login: login,
+		now:   now,
+	}
+}
+
+// Token returns the cached token, logging in first when there is none or it is about to expire.
+// While a login fails, the previous token is returned for as long as it is valid.
+func (m *TokenManager) Token(ctx context.Context) (*oauth2.Token, error) {
+	m.mu.RLock()
+	token, status := m.token, m.status
+	m.mu.RUnlock()
+
+	now := m.now()
+	if m.fresh(token, now) {
+		return token, nil
+	}
+
+	// don't hammer the token endpoint with credentials that just failed
+	if !status.Healthy() && now.Sub(status.LastErrorAt) < retryAfterFailure {
+		if m.valid(token, now) {
+			return token, nil
+		}
+		return nil, status.LastError
+	}
+
+	// the login is not bound to ctx, other requests may be waiting on it
+	ch := m.group.DoChan("login", func() (interface{}, error) {
+		return m.refresh()
+	})
+
+	select {
+	case res := <-ch:
+		if res.Err != nil {
+			if m.valid(token, m.now()) {
+				return token, nil
+			}
+			return nil, res.Err
+		}
+		return res.Val.(*oauth2.Token), nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// Set replaces the cached token, with one obtained outside of the manager.
+func (m *TokenManager) Set(token *oauth2.Token) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.token = token
+	m.status.LastLogin = m.now()
+	m.status.Expiry = token.Expiry
+}
+
+// Status returns the outcome of the latest logins.
+func (m *TokenManager) Status() Status {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.status
+}
+
+func (m *TokenManager) refresh() (*oauth2.Token, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), loginTimeout)
+	defer cancel()
+
+	token, err := m.login(ctx)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err != nil {
+		m.status.LastError = err
+		m.status.LastErrorAt = m.now()
+		return nil, err
+	}
+
+	m.token = token
+	m.status.LastLogin = m.now()
+	m.status.Expiry = token.Expiry
+	return token, nil
+}
+
+// fresh reports whether token can be used without logging in again.
+func (m *TokenManager) fresh(token *oauth2.Token, now time.Time) bool {
+	return m.valid(token, now) && (token.Expiry.IsZero() || token.Expiry.Sub(now) > refreshBefore)
+}
+
+// valid reports whether token has not expired yet.
+func (m *TokenManager) valid(token *oauth2.Token, now time.Time) bool {
+	return token != nil && token.AccessToken != "" && (token.Expiry.IsZero() || token.Expiry.After(now))
+}
@@ -0,0 +1,155 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/oauth2"
+)
+
+// fakeLogin hands out tokens valid for an hour of its clock, or fails with err.
+type fakeLogin struct {
+	now    time.Time
+	logins int32
+	err    error
+	// release, when set, holds every login until it is closed
+	release chan struct{}
+}
+
+func (f *fakeLogin) login(ctx context.Context) (*oauth2.Token, error) {
+	n := atomic.AddInt32(&f.logins, 1)
+	if f.release != nil {
+		<-f.release
+	}
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &oauth2.Token{AccessToken: fmt.Sprintf("token%d", n), Expiry: f.now.Add(time.Hour)}, nil
+}
+
+func newTestManager() (*TokenManager, *fakeLogin) {
+	f := &fakeLogin{now: time.Now()}
+	return NewTokenManager(f.login, func() time.Time { return f.now }), f
+}
+
+func TestTokenCached(t *testing.T) {
+	m, f := newTestManager()
+
+	token, err := m.Token(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "token1", token.AccessToken)
+
+	token, err = m.Token(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "token1", token.AccessToken)
+	assert.EqualValues(t, 1, f.logins)
+	assert.Equal(t, f.now.Add(time.Hour), m.Status().Expiry)
+}
+
+func TestTokenRefreshBefore(t *testing.T) {
+	m, f := newTestManager()
+	start := f.now
+
+	_, err := m.Token(context.Background())
+	require.NoError(t, err)
+
+	// still fresh just outside of the window
+	f.now = start.Add(time.Hour - refreshBefore - time.Second)
+	token, err := m.Token(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "token1", token.AccessToken)
+
+	// replaced once within it, before it expires
+	f.now = start.Add(time.Hour - refreshBefore + time.Second)
+	token, err = m.Token(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "token2", token.AccessToken)
+	assert.EqualValues(t, 2, f.logins)
+}
+
+func TestTokenSingleLogin(t *testing.T) {
+	m, f := newTestManager()
+	f.release = make(chan struct{})
+
+	const callers = 10
+	tokens := make(chan string, callers)
+	var wg sync.WaitGroup
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			token, err := m.Token(context.Background())
+			if assert.NoError(t, err) {
+				tokens <- token.AccessToken
+			}
+		}()
+	}
+
+	// every caller waits on the first login
+	require.Eventually(t, func() bool { return atomic.LoadInt32(&f.logins) == 1 }, time.Second, time.Millisecond)
+	close(f.release)
+	wg.Wait()
+	close(tokens)
+
+	assert.EqualValues(t, 1, f.logins)
+	for token := range tokens {
+		assert.Equal(t, "token1", token)
+	}
+}
+
+func TestTokenRetryAfterFailure(t *testing.T) {
+	m, f := newTestManager()
+	start := f.now
+	f.err = errors.New("invalid_grant")
+
+	_, err := m.Token(context.Background())
+	assert.EqualError(t, err, "invalid_grant")
+	assert.False(t, m.Status().Healthy())
+
+	// the failure is reported without logging in again for a while
+	f.now = start.Add(retryAfterFailure - time.Second)
+	_, err = m.Token(context.Background())
+	assert.EqualError(t, err, "invalid_grant")
+	assert.EqualValues(t, 1, f.logins)
+
+	f.err = nil
+	f.now = start.Add(retryAfterFailure)
+	token, err := m.Token(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "token2", token.AccessToken)
+	assert.True(t, m.Status().Healthy())
+}
+
+func TestTokenKeptWhileLoginFails(t *testing.T) {
+	m, f := newTestManager()
+	start := f.now
+
+	_, err := m.Token(context.Background())
+	require.NoError(t, err)
+
+	// the token is due for a refresh but still valid, it is used while logging in fails
+	f.err = errors.New("unavailable")
+	f.now = start.Add(time.Hour - time.Minute)
+	token, err := m.Token(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "token1", token.AccessToken)
+	assert.EqualValues(t, 2, f.logins)
+
+	token, err = m.Token(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "token1", token.AccessToken)
+	assert.EqualValues(t, 2, f.logins)
+
+	// and no longer once it expired
+	f.now = start.Add(time.Hour + retryAfterFailure)
+	_, err = m.Token(context.Background())
+	assert.EqualError(t, err, "unavailable")
+	assert.EqualValues(t, 3, f.logins)
+}
@@ -0,0 +1,49 @@
+package auth
+
+import (
+	"os"
+)
+
+// TokenFile keeps a refresh token on disk, encrypted with AES-GCM under a key derived from a passphrase.
+type TokenFile struct {
+	path string
+	key  [32]byte
+}
+
+// NewTokenFile uses the file at path, which is created on the first Save. passphrase should be long and random.
+func NewTokenFile(path string, passphrase string) *TokenFile {
+	return &TokenFile{
+		path: path,
+		key:  sealKey(passphrase),
+	}
+}
+
+// Load returns the stored refresh token. It fails with an error matching os.ErrNotExist when none was saved yet.
+func (f *TokenFile) Load() (string, error) {
+	sealed, err := os.ReadFile(f.path)
+	if err != nil {
+		return "", err
+	}
+
+	plaintext, err := unseal(f.key, sealed)
+	if err != nil {
+		return "", err
+	}
+
+	return string(plaintext), nil
+}
+
+// Save replaces the stored refresh token.
+func (f *TokenFile) Save(refreshToken string) error {
+	sealed, err := seal(f.key, []byte(refreshToken))
+	if err != nil {
+		return err
+	}
+
+	// write aside and rename, so a crash never leaves half a token behind
+	tmp := f.path + ".tmp"
+	if err := os.WriteFile(tmp, sealed, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, f.path)
+}
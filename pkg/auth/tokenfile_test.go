@@ -0,0 +1,41 @@
+package auth
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTokenFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "token")
+	file := NewTokenFile(path, "passphrase")
+
+	_, err := file.Load()
+	assert.ErrorIs(t, err, os.ErrNotExist)
+
+	require.NoError(t, file.Save("refresh1"))
+	refreshToken, err := file.Load()
+	require.NoError(t, err)
+	assert.Equal(t, "refresh1", refreshToken)
+
+	// only the owner may read it, and it is not stored in clear
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+	b, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.NotContains(t, string(b), "refresh1")
+
+	require.NoError(t, file.Save("refresh2"))
+	refreshToken, err = NewTokenFile(path, "passphrase").Load()
+	require.NoError(t, err)
+	assert.Equal(t, "refresh2", refreshToken)
+	_, err = os.Stat(path + ".tmp")
+	assert.ErrorIs(t, err, os.ErrNotExist)
+
+	_, err = NewTokenFile(path, "other passphrase").Load()
+	assert.Error(t, err)
+}
@@ -0,0 +1,149 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/cameronstanley/go-reddit"
+	"golang.org/x/oauth2"
+)
+
+// UserScopes are asked for to Reddit users authorizing their private feeds.
+var UserScopes = []string{
+	reddit.ScopeIdentity,
+	reddit.ScopeRead,
+	reddit.ScopeHistory,
+	reddit.ScopeMySubreddits,
+	reddit.ScopePrivateMessages,
+}
+
+// UserFlow lets Reddit users authorize their private feeds in a browser, then logs in as them.
+type UserFlow struct {
+	clientID     string
+	clientSecret string
+	redirectURL  string
+	userAgent    string
+	registry     *Registry
+	now          func() time.Time
+
+	mu     sync.Mutex
+	tokens map[string]*TokenManager
+}
+
+// NewUserFlow registers the users authorizing the app clientID, which redirects to redirectURL, in registry.
+func NewUserFlow(clientID, clientSecret, redirectURL, userAgent string, registry *Registry, now func() time.Time) *UserFlow {
+	return &UserFlow{
+		clientID:     clientID,
+		clientSecret: clientSecret,
+		redirectURL:  redirectURL,
+		userAgent:    userAgent,
+		registry:     registry,
+		now:          now,
+		tokens:       make(map[string]*TokenManager),
+	}
+}
+
+func (f *UserFlow) authenticator(state string) *reddit.Authenticator {
+	authenticator := reddit.NewAuthenticator(f.clientID, f.clientSecret, f.redirectURL, f.userAgent, state, UserScopes...)
+	authenticator.RequestPermanentToken = true
+	return authenticator
+}
+
+// AuthURL is the Reddit page asking a user to authorize the app. state must be random and
+// remembered by the browser until the callback, see Exchange.
+func (f *UserFlow) AuthURL(state string) string {
+	return f.authenticator(state).GetAuthenticationURL()
+}
+
+// Exchange trades the code Reddit redirected back with for a token and registers the user it belongs to.
+// expectedState is the state the browser was sent to AuthURL with, state the one Reddit redirected back with.
+func (f *UserFlow) Exchange(ctx context.Context, expectedState, state, code string) (User, error) {
+	if expectedState == "" {
+		return User{}, errors.New("invalid state")
+	}
+
+	authenticator := f.authenticator(expectedState)
+	token, err := authenticator.GetToken(ctx, state, code)
+	if err != nil {
+		return User{}, err
+	}
+	if token.RefreshToken == "" {
+		return User{}, errors.New("no refresh token was granted")
+	}
+
+	me, err := authenticator.GetAuthClient(token).GetMe(ctx)
+	if err != nil {
+		return User{}, err
+	}
+	if me.Name == "" {
+		return User{}, errors.New("unable to tell who authorized")
+	}
+
+	user, err := f.registry.Register(me.Name, token.RefreshToken, f.now())
+	if err != nil {
+		return User{}, err
+	}
+	f.manager(user.Name).Set(token)
+
+	return user, nil
+}
+
+// Token returns an access token of user.
+func (f *UserFlow) Token(ctx context.Context, user User) (*oauth2.Token, error) {
+	return f.manager(user.Name).Token(ctx)
+}
+
+// Revoke revokes the feed token, and the authorization of the user it was issued to on Reddit.
+func (f *UserFlow) Revoke(ctx context.Context, feedToken string) (User, error) {
+	user, err := f.registry.Revoke(feedToken)
+	if err != nil {
+		return User{}, err
+	}
+
+	f.mu.Lock()
+	delete(f.tokens, string(userKey(user.Name)))
+	f.mu.Unlock()
+
+	return user, f.authenticator("").RevokeToken(ctx, user.RefreshToken)
+}
+
+// manager returns the token manager of the user name, which logs in with their stored refresh token.
+func (f *UserFlow) manager(name string) *TokenManager {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	key := string(userKey(name))
+	m, ok := f.tokens[key]
+	if !ok {
+		m = NewTokenManager(func(ctx context.Context) (*oauth2.Token, error) {
+			return f.login(ctx, name)
+		}, f.now)
+		f.tokens[key] = m
+	}
+
+	return m
+}
+
+// login gets a new access token of the user name with their stored refresh token.
+func (f *UserFlow) login(ctx context.Context, name string) (*oauth2.Token, error) {
+	user, err := f.registry.User(name)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := f.authenticator("").RefreshToken(ctx, user.RefreshToken)
+	if err != nil {
+		return nil, err
+	}
+
+	// reddit may hand out a new refresh token
+	if token.RefreshToken != "" && token.RefreshToken != user.RefreshToken {
+		if err := f.registry.SetRefreshToken(name, token.RefreshToken); err != nil {
+			return nil, err
+		}
+	}
+
+	return token, nil
+}
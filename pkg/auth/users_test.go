@@ -0,0 +1,63 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// roundTripFunc answers requests with a function.
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// fakeTokenEndpoint answers the requests of the Reddit authenticator, which go through http.DefaultTransport,
+// with a token holding refreshToken.
+func fakeTokenEndpoint(t *testing.T, refreshToken string) {
+	transport := http.DefaultTransport
+	t.Cleanup(func() { http.DefaultTransport = transport })
+	http.DefaultTransport = roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		w := httptest.NewRecorder()
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteString(`{"access_token": "access", "token_type": "bearer", "expires_in": 3600, "refresh_token": "` + refreshToken + `"}`)
+		return w.Result(), nil
+	})
+}
+
+func TestUserFlowRotatedRefreshToken(t *testing.T) {
+	r := openTestRegistry(t)
+	user, err := r.Register("Alice", "refresh1", time.Now())
+	require.NoError(t, err)
+	f := NewUserFlow("id", "secret", "http://localhost/auth/callback", "test", r, time.Now)
+
+	fakeTokenEndpoint(t, "refresh2")
+	token, err := f.Token(context.Background(), user)
+	require.NoError(t, err)
+	assert.Equal(t, "access", token.AccessToken)
+
+	found, err := r.User("alice")
+	require.NoError(t, err)
+	assert.Equal(t, "refresh2", found.RefreshToken)
+}
+
+func TestUserFlowManagerName(t *testing.T) {
+	f := NewUserFlow("id", "secret", "http://localhost/auth/callback", "test", openTestRegistry(t), time.Now)
+
+	// reddit names are case insensitive, so is the token of their users
+	assert.Same(t, f.manager("Alice"), f.manager("alice"))
+	assert.NotSame(t, f.manager("alice"), f.manager("bob"))
+}
+
+func TestUserFlowInvalidState(t *testing.T) {
+	f := NewUserFlow("id", "secret", "http://localhost/auth/callback", "test", openTestRegistry(t), time.Now)
+
+	_, err := f.Exchange(context.Background(), "", "", "code")
+	assert.EqualError(t, err, "invalid state")
+}
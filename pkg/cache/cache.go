@@ -0,0 +1,66 @@
+// Package cache keeps feed responses and rendered articles for a while, in memory, on disk or in Redis.
+package cache
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	httpcache "github.com/victorspringer/http-cache"
+)
+
+// Cache stores values by key until they expire. It is safe for concurrent use.
+type Cache interface {
+	// Get returns the value stored under key, unless it expired.
+	Get(key string) ([]byte, bool)
+	// Set stores value under key for ttl.
+	Set(key string, value []byte, ttl time.Duration)
+	// Delete removes the value stored under key.
+	Delete(key string)
+}
+
+// responsePrefix keeps the responses of the http-cache middleware apart from other values sharing the cache.
+const responsePrefix = "response:"
+
+// Adapter lets the http-cache middleware store its responses in c. Responses marked Cache-Control: no-store are not stored.
+func Adapter(c Cache) httpcache.Adapter {
+	return &adapter{cache: c}
+}
+
+type adapter struct {
+	cache Cache
+}
+
+func (a *adapter) Get(key uint64) ([]byte, bool) {
+	return a.cache.Get(responsePrefix + httpcache.KeyAsString(key))
+}
+
+func (a *adapter) Set(key uint64, response []byte, expiration time.Time) {
+	if strings.Contains(httpcache.BytesToResponse(response).Header.Get("Cache-Control"), "no-store") {
+		return
+	}
+	a.cache.Set(responsePrefix+httpcache.KeyAsString(key), response, time.Until(expiration))
+}
+
+func (a *adapter) Release(key uint64) {
+	a.cache.Delete(responsePrefix + httpcache.KeyAsString(key))
+}
+
+// OnlyOK marks the responses of next other than 200 OK with Cache-Control: no-store. The http-cache middleware
+// keeps every status below 400 and replays them all as 200 OK, it goes between the middleware and next.
+func OnlyOK(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(&onlyOKWriter{ResponseWriter: w}, r)
+	})
+}
+
+type onlyOKWriter struct {
+	http.ResponseWriter
+}
+
+func (w *onlyOKWriter) WriteHeader(status int) {
+	if status != http.StatusOK {
+		w.Header().Set("Cache-Control", "no-store")
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
@@ -0,0 +1,51 @@
+package cache
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	httpcache "github.com/victorspringer/http-cache"
+)
+
+func TestAdapterOnlyOK(t *testing.T) {
+	m := NewMemory(1<<20, time.Now)
+	client, err := httpcache.NewClient(httpcache.ClientWithAdapter(Adapter(m)), httpcache.ClientWithTTL(time.Minute))
+	require.NoError(t, err)
+
+	renders := 0
+	h := client.Middleware(OnlyOK(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		renders++
+		if r.URL.Path == "/" {
+			http.Redirect(w, r, "https://example.com", http.StatusMovedPermanently)
+			return
+		}
+		io.WriteString(w, "feed")
+	})))
+
+	get := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		return w
+	}
+
+	// redirects are answered as they are, every time
+	for i := 0; i < 2; i++ {
+		w := get("/")
+		assert.Equal(t, http.StatusMovedPermanently, w.Code)
+		assert.Equal(t, "https://example.com", w.Header().Get("Location"))
+	}
+	assert.Equal(t, 2, renders)
+
+	assert.Equal(t, "feed", get("/r/golang").Body.String())
+	w := get("/r/golang")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "feed", w.Body.String())
+	assert.Empty(t, w.Header().Get("Cache-Control"))
+	assert.Equal(t, 3, renders)
+	assert.Equal(t, 1, m.Len())
+}
@@ -0,0 +1,128 @@
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Disk is a Cache keeping every value in its own file of a directory, which survives restarts.
+// Expired files are removed when they are read, or by Maintain.
+type Disk struct {
+	dir string
+	now func() time.Time
+}
+
+// NewDisk creates the directory dir if needed and returns a Disk cache storing its files there.
+func NewDisk(dir string, now func() time.Time) (*Disk, error) {
+	err := os.MkdirAll(dir, 0700)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Disk{dir: dir, now: now}, nil
+}
+
+// path returns the file of key, keys are hashed to be safe file names.
+func (d *Disk) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(d.dir, hex.EncodeToString(sum[:]))
+}
+
+// files hold the expiry time in unix nanoseconds followed by the value.
+func (d *Disk) Get(key string) ([]byte, bool) {
+	b, err := os.ReadFile(d.path(key))
+	if err != nil || len(b) < 8 {
+		return nil, false
+	}
+
+	if expires := time.Unix(0, int64(binary.BigEndian.Uint64(b[:8]))); !d.now().Before(expires) {
+		os.Remove(d.path(key))
+		return nil, false
+	}
+
+	return b[8:], true
+}
+
+func (d *Disk) Set(key string, value []byte, ttl time.Duration) {
+	if ttl <= 0 {
+		d.Delete(key)
+		return
+	}
+
+	b := make([]byte, 8+len(value))
+	binary.BigEndian.PutUint64(b[:8], uint64(d.now().Add(ttl).UnixNano()))
+	copy(b[8:], value)
+
+	// readers never see a partially written file
+	tmp, err := os.CreateTemp(d.dir, "*.tmp")
+	if err != nil {
+		return
+	}
+	_, err = tmp.Write(b)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), d.path(key))
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+	}
+}
+
+func (d *Disk) Delete(key string) {
+	os.Remove(d.path(key))
+}
+
+// Prune removes the files of expired values and returns how many there were.
+func (d *Disk) Prune() (int, error) {
+	entries, err := os.ReadDir(d.dir)
+	if err != nil {
+		return 0, err
+	}
+
+	removed := 0
+	now := d.now()
+	for _, entry := range entries {
+		path := filepath.Join(d.dir, entry.Name())
+		f, err := os.Open(path)
+		if err != nil {
+			continue
+		}
+		header := make([]byte, 8)
+		_, err = f.Read(header)
+		f.Close()
+
+		if err != nil || !now.Before(time.Unix(0, int64(binary.BigEndian.Uint64(header)))) {
+			if os.Remove(path) == nil {
+				removed++
+			}
+		}
+	}
+
+	return removed, nil
+}
+
+// Maintain prunes the cache every interval until stop is closed.
+func (d *Disk) Maintain(interval time.Duration, logf func(format string, v ...interface{}), stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			removed, err := d.Prune()
+			if err != nil {
+				logf("ERROR: Unable to prune the cache: %s", err.Error())
+				continue
+			}
+			logf("Pruned %d cached values", removed)
+		}
+	}
+}
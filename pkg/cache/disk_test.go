@@ -0,0 +1,68 @@
+package cache
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDisk(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Unix(1700000000, 0)
+	clock := func() time.Time { return now }
+	d, err := NewDisk(dir, clock)
+	require.NoError(t, err)
+
+	_, ok := d.Get("a")
+	assert.False(t, ok)
+
+	d.Set("a", []byte("one"), time.Minute)
+	d.Set("b", []byte("two"), time.Hour)
+	v, ok := d.Get("a")
+	assert.True(t, ok)
+	assert.Equal(t, "one", string(v))
+
+	// values survive restarts
+	d, err = NewDisk(dir, clock)
+	require.NoError(t, err)
+	v, ok = d.Get("b")
+	assert.True(t, ok)
+	assert.Equal(t, "two", string(v))
+
+	d.Delete("b")
+	_, ok = d.Get("b")
+	assert.False(t, ok)
+}
+
+func TestDiskExpiry(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Unix(1700000000, 0)
+	d, err := NewDisk(dir, func() time.Time { return now })
+	require.NoError(t, err)
+
+	d.Set("a", []byte("one"), time.Minute)
+	d.Set("b", []byte("two"), time.Minute)
+	d.Set("c", []byte("three"), time.Hour)
+	d.Set("d", []byte("four"), 0)
+
+	now = now.Add(time.Minute)
+	_, ok := d.Get("a")
+	assert.False(t, ok)
+	// the expired file was removed when read
+	_, err = os.Stat(d.path("a"))
+	assert.True(t, os.IsNotExist(err))
+
+	removed, err := d.Prune()
+	require.NoError(t, err)
+	assert.Equal(t, 1, removed)
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+	v, ok := d.Get("c")
+	assert.True(t, ok)
+	assert.Equal(t, "three", string(v))
+}
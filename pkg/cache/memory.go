@@ -0,0 +1,99 @@
+package cache
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// Memory is a Cache holding up to maxBytes of keys and values in memory, evicting the least recently used first.
+type Memory struct {
+	maxBytes int64
+	now      func() time.Time
+
+	mu      sync.Mutex
+	size    int64
+	lru     *list.List
+	entries map[string]*list.Element
+}
+
+type memoryEntry struct {
+	key     string
+	value   []byte
+	expires time.Time
+}
+
+func (e *memoryEntry) size() int64 {
+	return int64(len(e.key) + len(e.value))
+}
+
+// NewMemory creates an empty Memory cache of maxBytes.
+func NewMemory(maxBytes int64, now func() time.Time) *Memory {
+	return &Memory{
+		maxBytes: maxBytes,
+		now:      now,
+		lru:      list.New(),
+		entries:  make(map[string]*list.Element),
+	}
+}
+
+func (m *Memory) Get(key string) ([]byte, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	el, ok := m.entries[key]
+	if !ok {
+		return nil, false
+	}
+	entry := el.Value.(*memoryEntry)
+	if !m.now().Before(entry.expires) {
+		m.remove(el)
+		return nil, false
+	}
+
+	m.lru.MoveToFront(el)
+	return entry.value, true
+}
+
+func (m *Memory) Set(key string, value []byte, ttl time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if el, ok := m.entries[key]; ok {
+		m.remove(el)
+	}
+
+	entry := &memoryEntry{key: key, value: value, expires: m.now().Add(ttl)}
+	if ttl <= 0 || entry.size() > m.maxBytes {
+		return
+	}
+
+	m.entries[key] = m.lru.PushFront(entry)
+	m.size += entry.size()
+	for m.size > m.maxBytes {
+		m.remove(m.lru.Back())
+	}
+}
+
+func (m *Memory) Delete(key string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if el, ok := m.entries[key]; ok {
+		m.remove(el)
+	}
+}
+
+// Len returns the number of values held, expired ones included until they are evicted.
+func (m *Memory) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.lru.Len()
+}
+
+func (m *Memory) remove(el *list.Element) {
+	entry := m.lru.Remove(el).(*memoryEntry)
+	delete(m.entries, entry.key)
+	m.size -= entry.size()
+}
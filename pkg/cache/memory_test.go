@@ -0,0 +1,84 @@
+package cache
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryGetSet(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	m := NewMemory(1<<10, func() time.Time { return now })
+
+	_, ok := m.Get("a")
+	assert.False(t, ok)
+
+	m.Set("a", []byte("one"), time.Minute)
+	v, ok := m.Get("a")
+	assert.True(t, ok)
+	assert.Equal(t, "one", string(v))
+
+	m.Set("a", []byte("two"), time.Minute)
+	v, _ = m.Get("a")
+	assert.Equal(t, "two", string(v))
+	assert.Equal(t, 1, m.Len())
+
+	m.Delete("a")
+	_, ok = m.Get("a")
+	assert.False(t, ok)
+	assert.Zero(t, m.size)
+}
+
+func TestMemoryExpiry(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	m := NewMemory(1<<10, func() time.Time { return now })
+
+	m.Set("a", []byte("one"), time.Minute)
+	m.Set("b", []byte("two"), 0)
+	_, ok := m.Get("b")
+	assert.False(t, ok)
+
+	now = now.Add(time.Minute)
+	_, ok = m.Get("a")
+	assert.False(t, ok)
+	assert.Zero(t, m.Len())
+	assert.Zero(t, m.size)
+}
+
+func TestMemoryEvictsLeastRecentlyUsed(t *testing.T) {
+	m := NewMemory(30, time.Now)
+	value := []byte(strings.Repeat("x", 9))
+
+	// each entry takes 10 bytes, key included
+	m.Set("a", value, time.Minute)
+	m.Set("b", value, time.Minute)
+	m.Set("c", value, time.Minute)
+	assert.Equal(t, int64(30), m.size)
+
+	// reading a makes b the least recently used
+	_, ok := m.Get("a")
+	assert.True(t, ok)
+	m.Set("d", value, time.Minute)
+
+	_, ok = m.Get("b")
+	assert.False(t, ok)
+	for _, key := range []string{"a", "c", "d"} {
+		_, ok := m.Get(key)
+		assert.True(t, ok, key)
+	}
+
+	// a larger value evicts as many entries as needed
+	m.Set("e", []byte(strings.Repeat("x", 19)), time.Minute)
+	assert.Equal(t, 2, m.Len())
+	assert.Equal(t, int64(30), m.size)
+	_, ok = m.Get("c")
+	assert.False(t, ok)
+
+	// values larger than the cache are not kept, and don't evict anything
+	m.Set("f", []byte(strings.Repeat("x", 30)), time.Minute)
+	_, ok = m.Get("f")
+	assert.False(t, ok)
+	assert.Equal(t, 2, m.Len())
+}
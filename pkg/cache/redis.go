@@ -0,0 +1,62 @@
+package cache
+
+import (
+	"log"
+	"net/url"
+	"time"
+
+	"github.com/go-redis/redis"
+)
+
+// Redis is a Cache stored in a Redis server, which may be shared by several instances.
+type Redis struct {
+	client *redis.Ring
+}
+
+// NewRedis connects to the Redis server at rawURL, ie: redis://:password@host:6379.
+func NewRedis(rawURL string) (*Redis, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	pass, _ := u.User.Password()
+
+	return &Redis{
+		client: redis.NewRing(&redis.RingOptions{
+			Addrs: map[string]string{
+				"server": u.Host,
+			},
+			Password: pass,
+		}),
+	}, nil
+}
+
+func (r *Redis) Get(key string) ([]byte, bool) {
+	b, err := r.client.Get(key).Bytes()
+	if err != nil {
+		if err != redis.Nil {
+			log.Printf("WARN: Unable to read from the cache: %s", err.Error())
+		}
+		return nil, false
+	}
+	return b, true
+}
+
+func (r *Redis) Set(key string, value []byte, ttl time.Duration) {
+	if ttl <= 0 {
+		r.Delete(key)
+		return
+	}
+	if err := r.client.Set(key, value, ttl).Err(); err != nil {
+		log.Printf("WARN: Unable to write to the cache: %s", err.Error())
+	}
+}
+
+func (r *Redis) Delete(key string) {
+	r.client.Del(key)
+}
+
+// Close closes the connections to the server.
+func (r *Redis) Close() error {
+	return r.client.Close()
+}
@@ -0,0 +1,146 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	reddit "github.com/cameronstanley/go-reddit"
+	"github.com/sorae42/ressdit/pkg/cache"
+)
+
+// RefreshParam asks for a feed to be fetched again instead of served from the caches, it is also the refresh key of the feed cache in front of them.
+const RefreshParam = "opn"
+
+type refreshContextKey struct{}
+
+// refreshing tells whether r asked for its feed to be fetched again, the listings it reads are not taken from the cache.
+func refreshing(r *http.Request) bool {
+	refresh, _ := r.Context().Value(refreshContextKey{}).(bool)
+	return refresh
+}
+
+// feedParams are the query parameters read by ressdit itself, which are not sent to Reddit.
+var feedParams = []string{"safe", "scoreLimit", "flair", "filter", "pages", "items", "onlyNew", "digest", "top", "fulltext", "format", RefreshParam}
+
+// listingKey is the cache key of the Reddit listing at u, read from redditURL.
+func listingKey(redditURL string, u *url.URL) string {
+	// Encode sorts the parameters
+	return "listing:" + redditURL + u.Path + "?" + u.Query().Encode()
+}
+
+// NormalizeFeedURL rewrites feed requests so that every way of asking for the same feed has the same url,
+// ie: /r/golang.atom, /r/golang/?format=atom and /r/golang with an Atom Accept header all become /r/golang?format=atom.
+// Caches in front of next then key on the feed and its format alone.
+func NormalizeFeedURL(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		format, err := feedFormatFromRequest(r)
+		if err != nil {
+			writeError(w, time.Now, err)
+			return
+		}
+		if r.URL.Path != "/" {
+			r.URL.Path = strings.TrimSuffix(r.URL.Path, "/")
+		}
+
+		q := r.URL.Query()
+		if q.Has(RefreshParam) {
+			// caches in front of next take the parameter out of the url
+			r = r.WithContext(context.WithValue(r.Context(), refreshContextKey{}, true))
+		}
+		q.Set("format", format.String())
+		r.URL.RawQuery = q.Encode()
+		r.RequestURI = r.URL.RequestURI()
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// ConditionalGET answers conditional and range requests for the responses of next, usually a cache replaying whole
+// responses. next is asked for the whole response every time, so it never keeps a 304 or a part of a feed.
+func ConditionalGET(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		inner := r.Clone(r.Context())
+		for _, header := range []string{"If-None-Match", "If-Modified-Since", "If-Match", "If-Unmodified-Since", "If-Range", "Range"} {
+			inner.Header.Del(header)
+		}
+
+		resp := &bufferedResponse{header: make(http.Header), status: http.StatusOK}
+		next.ServeHTTP(resp, inner)
+
+		if resp.status != http.StatusOK {
+			resp.writeTo(w)
+			return
+		}
+		for key, values := range resp.header {
+			w.Header()[key] = values
+		}
+
+		// a missing or invalid Last-Modified leaves the ETag alone to decide
+		modified, _ := http.ParseTime(resp.header.Get("Last-Modified"))
+		http.ServeContent(w, withoutRange(r), "", modified, bytes.NewReader(resp.body.Bytes()))
+	})
+}
+
+// withoutRange returns r without its Range headers, so http.ServeContent only answers the conditional ones:
+// feeds are always sent whole.
+func withoutRange(r *http.Request) *http.Request {
+	if r.Header.Get("Range") == "" {
+		return r
+	}
+	r = r.Clone(r.Context())
+	r.Header.Del("Range")
+	r.Header.Del("If-Range")
+	return r
+}
+
+// bufferedResponse keeps a response in memory.
+type bufferedResponse struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (b *bufferedResponse) Header() http.Header {
+	return b.header
+}
+
+func (b *bufferedResponse) WriteHeader(status int) {
+	b.status = status
+}
+
+func (b *bufferedResponse) Write(p []byte) (int, error) {
+	return b.body.Write(p)
+}
+
+// writeTo writes the response to w.
+func (b *bufferedResponse) writeTo(w http.ResponseWriter) {
+	for key, values := range b.header {
+		w.Header()[key] = values
+	}
+	w.WriteHeader(b.status)
+	w.Write(b.body.Bytes())
+}
+
+// CachedArticles keeps the articles rendered by getArticle in c for ttl, by link and rendering options.
+func CachedArticles(c cache.Cache, ttl time.Duration, getArticle GetArticleFn) GetArticleFn {
+	return func(ctx context.Context, client *RedditClient, link *reddit.Link, opts ArticleOptions) (*string, error) {
+		key := fmt.Sprintf("article:%s:%t", link.ID, opts.FullText)
+		if b, ok := c.Get(key); ok {
+			article := string(b)
+			return &article, nil
+		}
+
+		article, err := getArticle(ctx, client, link, opts)
+		if err != nil || article == nil {
+			return article, err
+		}
+
+		c.Set(key, []byte(*article), ttl)
+		return article, nil
+	}
+}
@@ -0,0 +1,146 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/cameronstanley/go-reddit"
+	"github.com/sorae42/ressdit/pkg/cache"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeFeedURL(t *testing.T) {
+	var got string
+	var refresh bool
+	h := NormalizeFeedURL(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.URL.RequestURI()
+		refresh = refreshing(r)
+		assert.Equal(t, got, r.RequestURI)
+	}))
+
+	cases := []struct {
+		path   string
+		accept string
+		want   string
+	}{
+		{"/r/golang", "", "/r/golang?format=rss"},
+		{"/r/golang/", "", "/r/golang?format=rss"},
+		{"/r/golang.atom", "", "/r/golang?format=atom"},
+		{"/r/golang/?format=atom", "", "/r/golang?format=atom"},
+		{"/r/golang?format=ATOM", "", "/r/golang?format=atom"},
+		{"/r/golang", "application/atom+xml", "/r/golang?format=atom"},
+		{"/r/golang.jsonfeed", "application/atom+xml", "/r/golang?format=json"},
+		{"/r/golang?safe=true&scoreLimit=10", "", "/r/golang?format=rss&safe=true&scoreLimit=10"},
+		{"/r/golang?scoreLimit=10&safe=true", "", "/r/golang?format=rss&safe=true&scoreLimit=10"},
+		{"/", "", "/?format=rss"},
+	}
+	for _, c := range cases {
+		req := httptest.NewRequest("GET", c.path, nil)
+		if c.accept != "" {
+			req.Header.Set("Accept", c.accept)
+		}
+		h.ServeHTTP(httptest.NewRecorder(), req)
+		assert.Equal(t, c.want, got, c.path)
+		assert.False(t, refresh, c.path)
+	}
+
+	// an unknown format is answered before reaching next
+	got = ""
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/r/golang?format=yaml", nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "invalid format: yaml")
+	assert.Empty(t, got)
+
+	// the refresh parameter is left for the cache, and remembered in the context
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/r/golang?opn", nil))
+	assert.Equal(t, "/r/golang?format=rss&opn=", got)
+	assert.True(t, refresh)
+}
+
+func TestListingKey(t *testing.T) {
+	a, _ := url.Parse("/r/golang.json?t=week&sr_detail=1")
+	b, _ := url.Parse("/r/golang.json?sr_detail=1&t=week")
+	assert.Equal(t, listingKey("https://oauth.reddit.com", a), listingKey("https://oauth.reddit.com", b))
+	assert.NotEqual(t, listingKey("https://oauth.reddit.com", a), listingKey("https://www.reddit.com", a))
+}
+
+func TestSharedListing(t *testing.T) {
+	u := newUpstream(t, map[string]string{"/r/golang.json": "test_data/links.json"})
+	client := u.client()
+	client.Cache = cache.NewMemory(1<<20, time.Now)
+	client.ListingTTL = time.Minute
+
+	// the feeds differ by their filters and formats only, they are built from one fetch of the listing
+	for _, target := range []string{"/r/golang", "/r/golang?scoreLimit=100&format=atom", "/r/golang?safe=true&format=json"} {
+		w := serveFeedRequest(u, client, nil, httptest.NewRequest("GET", target, nil))
+		assert.Equal(t, http.StatusOK, w.Code, target)
+	}
+	assert.EqualValues(t, 1, atomic.LoadInt32(&u.requests))
+
+	// other listings are fetched on their own
+	serveFeedRequest(u, client, nil, httptest.NewRequest("GET", "/r/golang?t=week", nil))
+	assert.EqualValues(t, 2, atomic.LoadInt32(&u.requests))
+}
+
+func TestCachedArticles(t *testing.T) {
+	c := cache.NewMemory(1<<20, time.Now)
+	calls := 0
+	getArticle := CachedArticles(c, time.Hour, func(ctx context.Context, client *RedditClient, link *reddit.Link, opts ArticleOptions) (*string, error) {
+		calls++
+		return testArticle(ctx, client, link, opts)
+	})
+
+	link := &reddit.Link{ID: "abc"}
+	for i := 0; i < 2; i++ {
+		article, err := getArticle(context.Background(), nil, link, ArticleOptions{})
+		assert.NoError(t, err)
+		assert.Equal(t, "<p>abc</p>", *article)
+	}
+	assert.Equal(t, 1, calls)
+
+	// full text articles are cached apart
+	getArticle(context.Background(), nil, link, ArticleOptions{FullText: true})
+	assert.Equal(t, 2, calls)
+}
+
+func TestConditionalGET(t *testing.T) {
+	updated := time.Date(2024, 2, 8, 0, 0, 0, 0, time.UTC)
+	var inner http.Header
+	h := ConditionalGET(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		inner = r.Header.Clone()
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Last-Modified", updated.Format(http.TimeFormat))
+		w.Write([]byte("the whole feed"))
+	}))
+	serve := func(header http.Header) *httptest.ResponseRecorder {
+		r := httptest.NewRequest("GET", "/r/golang", nil)
+		for key, values := range header {
+			r.Header[key] = values
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w
+	}
+
+	w := serve(http.Header{"If-None-Match": {`"v1"`}})
+	assert.Equal(t, http.StatusNotModified, w.Code)
+	assert.Empty(t, inner.Get("If-None-Match"))
+
+	w = serve(http.Header{"If-Modified-Since": {updated.Format(http.TimeFormat)}})
+	assert.Equal(t, http.StatusNotModified, w.Code)
+
+	w = serve(http.Header{"If-None-Match": {`"v0"`}})
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "the whole feed", w.Body.String())
+
+	w = serve(http.Header{"Range": {"bytes=0-2"}})
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "the whole feed", w.Body.String())
+	assert.Empty(t, inner.Get("Range"))
+}
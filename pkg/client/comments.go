@@ -0,0 +1,114 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"log"
+	"net/http"
+	"regexp"
+	"time"
+
+	"github.com/cameronstanley/go-reddit"
+	"github.com/gorilla/feeds"
+)
+
+// commentsMoreRequests is the most requests made to load the comments Reddit left out of a thread.
+const commentsMoreRequests = 3
+
+// commentsPath matches the comment page of a single post, with or without its title slug.
+var commentsPath = regexp.MustCompile(`(?i)^\/r\/[a-z0-9_]+\/comments\/[a-z0-9]+(\/[^\/]*)?$`)
+
+// commentsFeed builds a feed of every comment on a post, newest first.
+func commentsFeed(redditURL string, client *RedditClient, opts feedOptions, r *http.Request) (*feeds.Feed, error) {
+	// the response holds two listings, the post itself followed by its comments
+	var result []json.RawMessage
+	err := fetchJSON(redditURL, client, r, commentsPath, "Post", &result)
+	if err != nil {
+		return nil, err
+	}
+	if len(result) < 2 {
+		return nil, &feedError{http.StatusNotFound, "Post not found."}
+	}
+
+	var links linkListing
+	err = json.Unmarshal(result[0], &links)
+	if err != nil {
+		return nil, fmt.Errorf("JSON: %w", err)
+	}
+	if len(links.Data.Children) == 0 {
+		return nil, &feedError{http.StatusNotFound, "Post not found."}
+	}
+	link := links.Data.Children[0].Data
+
+	var comments reddit.Replies
+	err = json.Unmarshal(result[1], &comments)
+	if err != nil {
+		return nil, fmt.Errorf("JSON: %w", err)
+	}
+
+	// large threads are sent in part, the rest is only listed
+	err = client.apiClient(redditURL).ExpandComments(r.Context(), link.ID, &comments, reddit.CommentTreeOptions{MoreRequests: commentsMoreRequests})
+	if err != nil {
+		log.Printf("WARN: Unable to load every comment of %s: %s", link.ID, err.Error())
+	}
+
+	feed := srDetailsFeed(link.SRDetails)
+	feed.Title = fmt.Sprintf("Comments on %s", link.Title)
+	feed.Link = &feeds.Link{Href: fmt.Sprintf("%s%s", frontendURL(), link.Permalink)}
+	feed.Description = fmt.Sprintf("Comments on \"%s\" in r/%s", link.Title, link.Subreddit)
+
+	// replies to filtered comments are still included
+	comments.Walk(-1, func(comment *reddit.Comment, depth int) error {
+		if opts.filter.match(commentLink(comment, &link)) {
+			feed.Items = append(feed.Items, commentToFeed(comment, link.Title))
+		}
+		return nil
+	})
+
+	feed.Sort(func(a, b *feeds.Item) bool {
+		return a.Created.After(b.Created)
+	})
+
+	return feed, nil
+}
+
+// commentLink views comment, made on post, as a link so the filters of link feeds apply to it.
+// The comment replaces the author, score, dates and text of the post, which keeps its title, domain, flair and nsfw flag.
+func commentLink(comment *reddit.Comment, post *reddit.Link) *reddit.Link {
+	link := *post
+	link.ID = comment.ID
+	link.Name = comment.Name
+	link.Author = comment.Author
+	link.AuthorFlairText = comment.AuthorFlairText
+	link.Score = comment.Score
+	link.Ups = comment.Ups
+	link.Gilded = comment.Gilded
+	link.CreatedUtc = comment.CreatedUtc
+	link.Selftext = comment.Body
+	link.Stickied = comment.Stickied
+	link.Archived = comment.Archived
+	return &link
+}
+
+// commentToFeed renders a comment on the post titled linkTitle.
+func commentToFeed(comment *reddit.Comment, linkTitle string) *feeds.Item {
+	itemLink := fmt.Sprintf("%s%s", frontendURL(), comment.Permalink)
+	content := html.UnescapeString(comment.BodyHTML)
+	content += fmt.Sprintf(`<p><small>%d points | <a href="%s">permalink</a></small></p>`, comment.Score, itemLink)
+
+	item := &feeds.Item{
+		Title:       fmt.Sprintf("/u/%s on %s", comment.Author, linkTitle),
+		Link:        &feeds.Link{Href: itemLink},
+		Description: comment.Body,
+		Author:      &feeds.Author{Name: comment.Author},
+		Created:     time.Unix(int64(comment.CreatedUtc), 0),
+		Id:          comment.ID,
+		Content:     content,
+	}
+	if comment.Edited > 0 {
+		item.Updated = time.Unix(int64(comment.Edited), 0)
+	}
+
+	return item
+}
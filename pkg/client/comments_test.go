@@ -0,0 +1,45 @@
+package client
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCommentsFeedFilters(t *testing.T) {
+	cases := []struct {
+		query string
+		want  []string
+	}{
+		{"", []string{"Range over integers", "Welcome to 2015"}},
+		{"?scoreLimit=10", []string{"Range over integers"}},
+		{"?filter=" + url.QueryEscape(`author=="rustacean"`), []string{"Welcome to 2015"}},
+		{"?filter=" + url.QueryEscape(`selftext~"(?i)range" && domain=="go.dev"`), []string{"Range over integers"}},
+		{"?flair=News", []string{"Range over integers", "Welcome to 2015"}},
+		{"?flair=Meme", nil},
+		{"?safe=true", []string{"Range over integers", "Welcome to 2015"}},
+	}
+	for _, c := range cases {
+		u := newUpstream(t, map[string]string{"/r/golang/comments/abc123.json": "test_data/comments.json"})
+		w := serveFeedRequest(u, u.client(), nil, httptest.NewRequest("GET", "/r/golang/comments/abc123"+c.query, nil))
+		assert.Equal(t, http.StatusOK, w.Code, c.query)
+
+		for _, body := range []string{"Range over integers", "Welcome to 2015"} {
+			if slices.Contains(c.want, body) {
+				assert.Contains(t, w.Body.String(), body, c.query)
+			} else {
+				assert.NotContains(t, w.Body.String(), body, c.query)
+			}
+		}
+	}
+}
+
+func TestCommentsFeedInvalidFilter(t *testing.T) {
+	u := newUpstream(t, map[string]string{"/r/golang/comments/abc123.json": "test_data/comments.json"})
+	w := serveFeedRequest(u, u.client(), nil, httptest.NewRequest("GET", "/r/golang/comments/abc123?filter=score>", nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
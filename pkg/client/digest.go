@@ -0,0 +1,177 @@
+package client
+
+import (
+	"fmt"
+	"html"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/cameronstanley/go-reddit"
+	"github.com/gorilla/feeds"
+	"github.com/graph-gophers/dataloader"
+)
+
+const (
+	digestDaily  = "daily"
+	digestWeekly = "weekly"
+
+	defaultDigestTop = 10
+	maxDigestTop     = 100
+)
+
+// digestOptions asks for one item per period bundling its top posts, instead of one item per post.
+type digestOptions struct {
+	period string
+	top    int
+}
+
+func newDigestOptions(r *http.Request) (digestOptions, error) {
+	opts := digestOptions{
+		period: strings.ToLower(r.URL.Query().Get("digest")),
+		top:    defaultDigestTop,
+	}
+
+	switch opts.period {
+	case "", digestDaily, digestWeekly:
+	default:
+		return opts, &feedError{http.StatusBadRequest, fmt.Sprintf("invalid digest: %s, expected daily or weekly", opts.period)}
+	}
+
+	if _, ok := r.URL.Query()["top"]; ok {
+		top, ok := queryInt(r, "top")
+		if !ok || top < 1 {
+			return opts, &feedError{http.StatusBadRequest, "invalid top, expected a positive number"}
+		}
+		opts.top = min(top, maxDigestTop)
+	}
+
+	return opts, nil
+}
+
+// periodStart returns the start of the digest period t belongs to, in UTC. Weeks start on monday.
+func (o digestOptions) periodStart(t time.Time) time.Time {
+	t = t.UTC()
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	if o.period == digestWeekly {
+		offset := (int(day.Weekday()) + 6) % 7
+		day = day.AddDate(0, 0, -offset)
+	}
+	return day
+}
+
+func (o digestOptions) periodTitle(start time.Time) string {
+	if o.period == digestWeekly {
+		return fmt.Sprintf("week of %s", start.Format("January 2, 2006"))
+	}
+	return start.Format("Monday, January 2, 2006")
+}
+
+// digestGroup holds the posts of one digest period, best first.
+type digestGroup struct {
+	start time.Time
+	links []reddit.Link
+}
+
+// addDigests groups the links that pass the filters by the period they were created in,
+// and adds one item per period with its top posts to feed.
+func addDigests(r *http.Request, feed *feeds.Feed, client *RedditClient, getArticle GetArticleFn, opts feedOptions, children []linkListingChildren) {
+	ctx := r.Context()
+
+	groups := make(map[time.Time]*digestGroup)
+	for _, child := range children {
+		if !opts.filter.match(&child.Data) {
+			continue
+		}
+
+		start := opts.digest.periodStart(time.Unix(int64(child.Data.CreatedUtc), 0))
+		group, ok := groups[start]
+		if !ok {
+			group = &digestGroup{start: start}
+			groups[start] = group
+		}
+		group.links = append(group.links, child.Data)
+	}
+
+	var sorted []*digestGroup
+	for _, group := range groups {
+		sort.SliceStable(group.links, func(i, j int) bool {
+			return group.links[i].Score > group.links[j].Score
+		})
+		if len(group.links) > opts.digest.top {
+			group.links = group.links[:opts.digest.top]
+		}
+		sorted = append(sorted, group)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].start.After(sorted[j].start)
+	})
+
+	// only the posts making it into a digest are rendered
+	loader := articleLoader(client, getArticle, opts.article)
+	thunks := make(map[string]dataloader.Thunk)
+	for _, group := range sorted {
+		for _, link := range group.links {
+			thunks[link.ID] = loader.Load(ctx, dataKey(link))
+		}
+	}
+
+	for _, group := range sorted {
+		var b strings.Builder
+		var updated time.Time
+		for i, link := range group.links {
+			var content string
+			if val, err := thunks[link.ID](); err == nil {
+				content = val.(*feeds.Item).Content
+			}
+			b.WriteString(digestEntry(i+1, &link, content))
+
+			if created := time.Unix(int64(link.CreatedUtc), 0); created.After(updated) {
+				updated = created
+			}
+		}
+
+		title := fmt.Sprintf("Top posts of %s", opts.digest.periodTitle(group.start))
+		if feed.Title != "" {
+			title = fmt.Sprintf("%s: %s", feed.Title, title)
+		}
+
+		feed.Items = append(feed.Items, &feeds.Item{
+			Title:   title,
+			Link:    feed.Link,
+			Id:      digestID(r, opts.digest.period, group.start),
+			Created: group.start,
+			Updated: updated,
+			Content: b.String(),
+		})
+	}
+}
+
+// digestID identifies the digest of a feed for a period, it stays the same between requests.
+func digestID(r *http.Request, period string, start time.Time) string {
+	return fmt.Sprintf("%s:%s:%s", strings.ToLower(r.URL.Path), period, start.Format("2006-01-02"))
+}
+
+// digestEntry renders one post of a digest with its article snippet.
+func digestEntry(rank int, link *reddit.Link, content string) string {
+	permalink := fmt.Sprintf("%s%s", frontendURL(), link.Permalink)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<h3>%d. <a href="%s">%s</a></h3>`, rank, permalink, html.EscapeString(link.Title))
+	fmt.Fprintf(&b, `<p><small>%d points | <a href="%s">%d comments</a> | r/%s | by /u/%s</small></p>`,
+		link.Score, permalink, link.NumComments, link.Subreddit, link.Author)
+
+	if strings.HasPrefix(link.Thumbnail, "http") {
+		fmt.Fprintf(&b, `<p><a href="%s"><img src="%s" /></a></p>`, permalink, link.Thumbnail)
+	}
+	if content != "" {
+		fmt.Fprintf(&b, `<div>%s</div>`, content)
+	}
+	if !link.IsSelf && link.URL != "" {
+		fmt.Fprintf(&b, `<p><a href="%s">%s</a></p>`, link.URL, html.EscapeString(link.Domain))
+	}
+	b.WriteString("<hr/>")
+
+	return b.String()
+}
@@ -0,0 +1,87 @@
+package client
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cameronstanley/go-reddit"
+	"github.com/gorilla/feeds"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPeriodStart(t *testing.T) {
+	paris := time.FixedZone("Paris", 2*60*60)
+	cases := []struct {
+		period string
+		t      time.Time
+		want   time.Time
+	}{
+		{digestDaily, time.Date(2024, 2, 8, 15, 30, 0, 0, time.UTC), time.Date(2024, 2, 8, 0, 0, 0, 0, time.UTC)},
+		{digestDaily, time.Date(2024, 2, 8, 0, 0, 0, 0, time.UTC), time.Date(2024, 2, 8, 0, 0, 0, 0, time.UTC)},
+		// 1am in Paris is still the previous day in UTC
+		{digestDaily, time.Date(2024, 2, 8, 1, 0, 0, 0, paris), time.Date(2024, 2, 7, 0, 0, 0, 0, time.UTC)},
+		// 2024-02-08 is a thursday
+		{digestWeekly, time.Date(2024, 2, 8, 15, 30, 0, 0, time.UTC), time.Date(2024, 2, 5, 0, 0, 0, 0, time.UTC)},
+		{digestWeekly, time.Date(2024, 2, 5, 0, 0, 0, 0, time.UTC), time.Date(2024, 2, 5, 0, 0, 0, 0, time.UTC)},
+		{digestWeekly, time.Date(2024, 2, 11, 23, 59, 0, 0, time.UTC), time.Date(2024, 2, 5, 0, 0, 0, 0, time.UTC)},
+		// weeks span months and years
+		{digestWeekly, time.Date(2024, 1, 2, 12, 0, 0, 0, time.UTC), time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{digestWeekly, time.Date(2023, 1, 1, 12, 0, 0, 0, time.UTC), time.Date(2022, 12, 26, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, c := range cases {
+		got := digestOptions{period: c.period}.periodStart(c.t)
+		assert.Equal(t, c.want, got, "%s %s", c.period, c.t)
+	}
+}
+
+func TestAddDigestsTop(t *testing.T) {
+	day := time.Date(2024, 2, 8, 0, 0, 0, 0, time.UTC)
+	post := func(id string, score int, created time.Time) linkListingChildren {
+		return linkListingChildren{Kind: "t3", Data: reddit.Link{ID: id, Title: "Post " + id, Score: score, CreatedUtc: float64(created.Unix())}}
+	}
+	children := []linkListingChildren{
+		post("low", 1, day.Add(time.Hour)),
+		post("best", 300, day.Add(2*time.Hour)),
+		post("good", 200, day.Add(3*time.Hour)),
+		post("okay", 100, day.Add(4*time.Hour)),
+		post("yesterday", 1000, day.Add(-time.Hour)),
+	}
+
+	r := httptest.NewRequest("GET", "/r/golang?digest=daily&top=2", nil)
+	opts, err := newFeedOptions(r)
+	require.NoError(t, err)
+	feed := &feeds.Feed{Title: "r/golang"}
+	addDigests(r, feed, nil, testArticle, opts, children)
+
+	require.Len(t, feed.Items, 2)
+	today, yesterday := feed.Items[0], feed.Items[1]
+	assert.Equal(t, day, today.Created)
+	assert.Equal(t, day.Add(3*time.Hour), today.Updated.UTC())
+	assert.Equal(t, "r/golang: Top posts of Thursday, February 8, 2024", today.Title)
+
+	// the best posts first, the others left out
+	best, good := strings.Index(today.Content, "Post best"), strings.Index(today.Content, "Post good")
+	assert.True(t, best >= 0 && good > best, today.Content)
+	assert.NotContains(t, today.Content, "Post okay")
+	assert.NotContains(t, today.Content, "Post low")
+
+	assert.Equal(t, day.AddDate(0, 0, -1), yesterday.Created)
+	assert.Contains(t, yesterday.Content, "Post yesterday")
+}
+
+func TestDigestID(t *testing.T) {
+	start := time.Date(2024, 2, 5, 0, 0, 0, 0, time.UTC)
+	opts := digestOptions{period: digestWeekly}
+
+	// any time of the week and any other parameter give the same id
+	a := digestID(httptest.NewRequest("GET", "/r/golang?digest=weekly", nil), digestWeekly, opts.periodStart(start.Add(time.Hour)))
+	b := digestID(httptest.NewRequest("GET", "/r/Golang?digest=weekly&top=5", nil), digestWeekly, opts.periodStart(start.AddDate(0, 0, 6)))
+	assert.Equal(t, "/r/golang:weekly:2024-02-05", a)
+	assert.Equal(t, a, b)
+
+	assert.NotEqual(t, a, digestID(httptest.NewRequest("GET", "/r/golang", nil), digestDaily, start))
+	assert.NotEqual(t, a, digestID(httptest.NewRequest("GET", "/r/golang", nil), digestWeekly, start.AddDate(0, 0, 7)))
+}
@@ -0,0 +1,164 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"mime"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/gabriel-vasile/mimetype"
+)
+
+const (
+	// fetchTimeout bounds every request to the sites linked by posts, body included.
+	fetchTimeout = 8 * time.Second
+	// maxPageSize is the most bytes read from a linked page, the rest is ignored.
+	maxPageSize = 2 << 20
+	// sniffSize is the most bytes read to detect the type of a linked file.
+	sniffSize = 3072
+	// fetchRetries is how many times a request failing with a broken connection or a server error is retried.
+	fetchRetries = 2
+	// fetchBackoff is the wait before the first retry, it doubles with each one and is jittered.
+	fetchBackoff = 200 * time.Millisecond
+)
+
+// NewTransport returns the transport shared by the requests to Reddit and to the sites linked by posts,
+// which gives up on hosts that are slow to connect or to answer. It is built from scratch rather than from
+// http.DefaultTransport, so certificates are always verified.
+func NewTransport() *http.Transport {
+	return &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			Timeout:   5 * time.Second,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          100,
+		MaxIdleConnsPerHost:   10,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   5 * time.Second,
+		ResponseHeaderTimeout: 10 * time.Second,
+		ExpectContinueTimeout: time.Second,
+	}
+}
+
+// fetchedPage is a response of a linked site, its body read up to a limit.
+type fetchedPage struct {
+	status int
+	header http.Header
+	body   []byte
+}
+
+// fetch requests pageURL with client, within fetchTimeout of ctx, and reads at most limit bytes of the body.
+// Broken connections and server errors are retried, other statuses are returned as they are.
+func fetch(ctx context.Context, client *http.Client, method, pageURL string, header http.Header, limit int64) (*fetchedPage, error) {
+	var page *fetchedPage
+	var err error
+	for attempt := 0; ; attempt++ {
+		page, err = fetchOnce(ctx, client, method, pageURL, header, limit)
+		if attempt == fetchRetries || !retryableFetch(page, err) {
+			break
+		}
+
+		// jitter keeps the retries of a batch from hitting a site at once
+		backoff := fetchBackoff << attempt
+		backoff += time.Duration(rand.Int63n(int64(backoff)))
+		timer := time.NewTimer(backoff)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		}
+	}
+
+	if err != nil {
+		return nil, err
+	}
+	return page, nil
+}
+
+func fetchOnce(ctx context.Context, client *http.Client, method, pageURL string, header http.Header, limit int64) (*fetchedPage, error) {
+	ctx, cancel := context.WithTimeout(ctx, fetchTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, method, pageURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, limit))
+	if err != nil {
+		return nil, err
+	}
+
+	return &fetchedPage{status: resp.StatusCode, header: resp.Header, body: body}, nil
+}
+
+// retryableFetch reports whether a fetch may succeed when tried again. Timeouts are not retried,
+// a slow site stays slow.
+func retryableFetch(page *fetchedPage, err error) bool {
+	if err != nil {
+		var netErr net.Error
+		if (errors.As(err, &netErr) && netErr.Timeout()) || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+			return false
+		}
+		return errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) || errors.As(err, new(*net.OpError))
+	}
+
+	switch page.status {
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// fetchPage GETs the page at pageURL, failing on error statuses.
+func fetchPage(ctx context.Context, client *http.Client, pageURL string) (*fetchedPage, error) {
+	page, err := fetch(ctx, client, http.MethodGet, pageURL, nil, maxPageSize)
+	if err != nil {
+		return nil, err
+	}
+	if page.status >= 400 {
+		return nil, fmt.Errorf("HTTP Status Code: %d", page.status)
+	}
+	return page, nil
+}
+
+// getMimeType detects the type of the file at fileURL. The Content-Type of a HEAD request is trusted when it is
+// specific, otherwise the first bytes of the file are requested with a Range and sniffed.
+func getMimeType(ctx context.Context, client *http.Client, fileURL string) (*mimetype.MIME, error) {
+	head, err := fetch(ctx, client, http.MethodHead, fileURL, nil, 0)
+	if err == nil && head.status < 400 {
+		if mediaType, _, err := mime.ParseMediaType(head.header.Get("Content-Type")); err == nil && mediaType != "application/octet-stream" {
+			if m := mimetype.Lookup(mediaType); m != nil {
+				return m, nil
+			}
+		}
+	}
+
+	// servers ignoring the range send the whole file, only its start is read
+	page, err := fetch(ctx, client, http.MethodGet, fileURL, http.Header{"Range": {fmt.Sprintf("bytes=0-%d", sniffSize-1)}}, sniffSize)
+	if err != nil {
+		return nil, err
+	}
+	if page.status >= 400 {
+		return nil, fmt.Errorf("HTTP Status Code: %d", page.status)
+	}
+
+	return mimetype.Detect(page.body), nil
+}
@@ -0,0 +1,107 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// pngHeader is enough of a PNG file to be sniffed as one.
+var pngHeader = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+
+func TestFetchRetry(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch atomic.AddInt32(&requests, 1) {
+		case 1, 2:
+			http.Error(w, "busy", http.StatusServiceUnavailable)
+		default:
+			w.Write([]byte("page"))
+		}
+	}))
+	defer server.Close()
+
+	page, err := fetchPage(context.Background(), server.Client(), server.URL)
+	require.NoError(t, err)
+	assert.Equal(t, "page", string(page.body))
+	assert.EqualValues(t, 3, requests)
+}
+
+func TestFetchNotRetried(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		http.NotFound(w, r)
+	}))
+	defer server.Close()
+
+	_, err := fetchPage(context.Background(), server.Client(), server.URL)
+	assert.EqualError(t, err, "HTTP Status Code: 404")
+	assert.EqualValues(t, 1, requests)
+}
+
+func TestFetchPageSize(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(bytes.Repeat([]byte("a"), maxPageSize+1024))
+	}))
+	defer server.Close()
+
+	page, err := fetchPage(context.Background(), server.Client(), server.URL)
+	require.NoError(t, err)
+	assert.Len(t, page.body, maxPageSize)
+}
+
+func TestGetMimeType(t *testing.T) {
+	cases := []struct {
+		name      string
+		head      func(w http.ResponseWriter)
+		wantMime  string
+		wantRange bool
+	}{
+		{"content type", func(w http.ResponseWriter) { w.Header().Set("Content-Type", "image/jpeg") }, "image/jpeg", false},
+		{"octet stream", func(w http.ResponseWriter) { w.Header().Set("Content-Type", "application/octet-stream") }, "image/png", true},
+		{"head refused", func(w http.ResponseWriter) { w.WriteHeader(http.StatusMethodNotAllowed) }, "image/png", true},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var ranged int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method == http.MethodHead {
+					c.head(w)
+					return
+				}
+				assert.Equal(t, "bytes=0-3071", r.Header.Get("Range"))
+				atomic.AddInt32(&ranged, 1)
+				w.Header().Set("Content-Type", "application/octet-stream")
+				w.Write(pngHeader)
+			}))
+			defer server.Close()
+
+			m, err := getMimeType(context.Background(), server.Client(), server.URL)
+			require.NoError(t, err)
+			assert.True(t, m.Is(c.wantMime), m.String())
+			assert.Equal(t, c.wantRange, ranged == 1)
+		})
+	}
+}
+
+func TestNewTransportVerifies(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	// main turns verification off for the default transport, it must not leak into ours
+	defaultTransport := http.DefaultTransport.(*http.Transport)
+	tlsConfig := defaultTransport.TLSClientConfig
+	defaultTransport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+	defer func() { defaultTransport.TLSClientConfig = tlsConfig }()
+
+	_, err := (&http.Client{Transport: NewTransport()}).Get(server.URL)
+	assert.ErrorContains(t, err, "certificate")
+}
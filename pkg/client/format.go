@@ -0,0 +1,192 @@
+package client
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/feeds"
+)
+
+type feedFormat int
+
+const (
+	formatRss feedFormat = iota
+	formatAtom
+	formatJSON
+)
+
+var feedFormatNames = map[string]feedFormat{
+	"rss":      formatRss,
+	"atom":     formatAtom,
+	"json":     formatJSON,
+	"jsonfeed": formatJSON,
+}
+
+var feedFormatSuffixes = map[string]feedFormat{
+	".rss":      formatRss,
+	".atom":     formatAtom,
+	".jsonfeed": formatJSON,
+}
+
+var feedFormatMediaTypes = map[string]feedFormat{
+	"application/rss+xml":   formatRss,
+	"application/atom+xml":  formatAtom,
+	"application/feed+json": formatJSON,
+	"application/json":      formatJSON,
+}
+
+func (f feedFormat) String() string {
+	switch f {
+	case formatAtom:
+		return "atom"
+	case formatJSON:
+		return "json"
+	default:
+		return "rss"
+	}
+}
+
+func (f feedFormat) contentType() string {
+	switch f {
+	case formatAtom:
+		return "application/atom+xml"
+	case formatJSON:
+		return "application/feed+json"
+	default:
+		return "application/rss+xml"
+	}
+}
+
+// feedFormatFromRequest picks the output format of a feed request. The "format" query parameter
+// wins over a ".atom"/".jsonfeed"/".rss" path suffix, which wins over the Accept header.
+// The suffix and the query parameter are removed from r.URL so they are not sent to Reddit.
+// An unknown "format" is an error rather than a feed the reader did not ask for.
+func feedFormatFromRequest(r *http.Request) (feedFormat, error) {
+	format := formatRss
+	negotiated := false
+
+	for suffix, f := range feedFormatSuffixes {
+		if strings.HasSuffix(r.URL.Path, suffix) {
+			r.URL.Path = strings.TrimSuffix(r.URL.Path, suffix)
+			format = f
+			negotiated = true
+			break
+		}
+	}
+
+	q := r.URL.Query()
+	if name := q.Get("format"); name != "" {
+		f, ok := feedFormatNames[strings.ToLower(name)]
+		if !ok {
+			return format, &feedError{http.StatusBadRequest, fmt.Sprintf("invalid format: %s, expected rss, atom or json", name)}
+		}
+		format = f
+		negotiated = true
+		q.Del("format")
+		r.URL.RawQuery = q.Encode()
+	}
+
+	if negotiated {
+		return format, nil
+	}
+
+	// no explicit choice, let the Accept header decide
+	if f, ok := acceptedFormat(r.Header.Get("Accept")); ok {
+		return f, nil
+	}
+
+	return format, nil
+}
+
+// acceptedFormat returns the format of the media type we know with the highest weight in the Accept header accept,
+// the first one listed among equals. Media types weighted q=0 are refused.
+func acceptedFormat(accept string) (feedFormat, bool) {
+	format, found := formatRss, false
+	best := 0.0
+	for _, mediaRange := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(mediaRange)
+		if err != nil {
+			continue
+		}
+		f, ok := feedFormatMediaTypes[mediaType]
+		if !ok {
+			continue
+		}
+
+		weight := 1.0
+		if q, ok := params["q"]; ok {
+			weight, err = strconv.ParseFloat(q, 64)
+			if err != nil {
+				continue
+			}
+		}
+		if weight > best {
+			format, found, best = f, true, weight
+		}
+	}
+
+	return format, found
+}
+
+// feedETag identifies the content of feed in format, it changes whenever an item does.
+func feedETag(feed *feeds.Feed, format feedFormat) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\x00%s\x00", format, feed.Title)
+	for _, item := range feed.Items {
+		link := ""
+		if item.Link != nil {
+			link = item.Link.Href
+		}
+		fmt.Fprintf(h, "%s\x00%s\x00%s\x00%s\x00%s\x00%d\x00%d\x00", item.Id, item.Title, link, item.Description, item.Content, item.Created.Unix(), item.Updated.Unix())
+	}
+	return `"` + hex.EncodeToString(h.Sum(nil)[:16]) + `"`
+}
+
+// writeFeed serializes feed in the given format and writes it to w, or answers 304 Not Modified
+// when the conditional headers of r show the client has it already.
+func writeFeed(w http.ResponseWriter, r *http.Request, feed *feeds.Feed, format feedFormat) {
+	// Atom requires an updated timestamp, use the newest item
+	for _, item := range feed.Items {
+		if item.Created.After(feed.Updated) {
+			feed.Updated = item.Created
+		}
+		if item.Updated.After(feed.Updated) {
+			feed.Updated = item.Updated
+		}
+	}
+
+	var body string
+	var err error
+	switch format {
+	case formatAtom:
+		body, err = feed.ToAtom()
+	case formatJSON:
+		jsonFeed := (&feeds.JSON{Feed: feed}).JSONFeed()
+		if feed.Image != nil {
+			jsonFeed.Icon = feed.Image.Url
+		}
+		body, err = jsonFeed.ToJSON()
+	default:
+		body, err = feed.ToRss()
+	}
+	if err != nil {
+		http.Error(w, err.Error(), 500)
+		return
+	}
+
+	w.Header().Set("Content-Type", format.contentType())
+	// private feeds and messages mark themselves before
+	if w.Header().Get("Cache-Control") == "" {
+		w.Header().Set("Cache-Control", "public, max-age=1800")
+	}
+	w.Header().Add("Vary", "Accept")
+	w.Header().Set("ETag", feedETag(feed, format))
+
+	// Last-Modified is left out when the feed has no items
+	http.ServeContent(w, withoutRange(r), "", feed.Updated, strings.NewReader(body))
+}
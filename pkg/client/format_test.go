@@ -0,0 +1,123 @@
+package client
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/feeds"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFeedFormatFromRequest(t *testing.T) {
+	cases := []struct {
+		path   string
+		accept string
+		want   feedFormat
+		path2  string
+	}{
+		{"/r/golang", "", formatRss, "/r/golang"},
+		{"/r/golang", "application/atom+xml", formatAtom, "/r/golang"},
+		{"/r/golang.jsonfeed", "application/atom+xml", formatJSON, "/r/golang"},
+		{"/r/golang.atom?format=json", "application/rss+xml", formatJSON, "/r/golang"},
+		{"/r/golang?format=atom", "application/feed+json", formatAtom, "/r/golang"},
+		{"/r/golang?format=JSONFeed", "application/atom+xml", formatJSON, "/r/golang"},
+		{"/r/golang.rss", "application/atom+xml", formatRss, "/r/golang"},
+	}
+	for _, c := range cases {
+		r := httptest.NewRequest("GET", c.path, nil)
+		r.Header.Set("Accept", c.accept)
+		format, err := feedFormatFromRequest(r)
+		require.NoError(t, err, c.path)
+		assert.Equal(t, c.want, format, "%s %s", c.path, c.accept)
+		assert.Equal(t, c.path2, r.URL.Path, c.path)
+		assert.Empty(t, r.URL.Query().Get("format"), c.path)
+	}
+
+	// an unknown format is refused, even when the Accept header has one
+	for _, path := range []string{"/r/golang?format=unknown", "/r/golang.atom?format=xml"} {
+		r := httptest.NewRequest("GET", path, nil)
+		r.Header.Set("Accept", "application/atom+xml")
+		_, err := feedFormatFromRequest(r)
+		var feedErr *feedError
+		if assert.ErrorAs(t, err, &feedErr, path) {
+			assert.Equal(t, http.StatusBadRequest, feedErr.status, path)
+		}
+	}
+}
+
+func TestAcceptedFormat(t *testing.T) {
+	cases := []struct {
+		accept string
+		want   feedFormat
+		ok     bool
+	}{
+		{"", formatRss, false},
+		{"text/html, */*", formatRss, false},
+		{"application/atom+xml", formatAtom, true},
+		{"application/rss+xml, application/atom+xml", formatRss, true},
+		{"application/rss+xml;q=0.5, application/atom+xml", formatAtom, true},
+		{"application/rss+xml;q=0.9, application/atom+xml;q=0.8, application/feed+json;q=0.95", formatJSON, true},
+		{"Application/Atom+XML; charset=utf-8; q=0.7, text/html", formatAtom, true},
+		{"application/atom+xml;q=0, application/rss+xml;q=0.1", formatRss, true},
+		{"application/atom+xml;q=0", formatRss, false},
+		{"application/atom+xml;q=abc, application/rss+xml;q=0.1", formatRss, true},
+	}
+	for _, c := range cases {
+		f, ok := acceptedFormat(c.accept)
+		assert.Equal(t, c.want, f, c.accept)
+		assert.Equal(t, c.ok, ok, c.accept)
+	}
+}
+
+func TestWriteFeedConditional(t *testing.T) {
+	updated := time.Date(2024, 2, 8, 0, 0, 0, 0, time.UTC)
+	feed := func() *feeds.Feed {
+		return &feeds.Feed{
+			Title: "r/golang",
+			Link:  &feeds.Link{Href: "https://www.reddit.com/r/golang"},
+			Items: []*feeds.Item{{Id: "a", Title: "Go 1.22 is released", Link: &feeds.Link{Href: "https://go.dev"}, Created: updated}},
+		}
+	}
+	write := func(header http.Header) *httptest.ResponseRecorder {
+		r := httptest.NewRequest("GET", "/r/golang", nil)
+		for key, values := range header {
+			r.Header[key] = values
+		}
+		w := httptest.NewRecorder()
+		writeFeed(w, r, feed(), formatRss)
+		return w
+	}
+
+	w := write(nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	etag := w.Header().Get("ETag")
+	require.NotEmpty(t, etag)
+	assert.Equal(t, updated.Format(http.TimeFormat), w.Header().Get("Last-Modified"))
+	full := w.Body.String()
+
+	w = write(http.Header{"If-None-Match": {etag}})
+	assert.Equal(t, http.StatusNotModified, w.Code)
+	assert.Empty(t, w.Body.String())
+
+	w = write(http.Header{"If-None-Match": {`"other"`}})
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = write(http.Header{"If-Modified-Since": {updated.Format(http.TimeFormat)}})
+	assert.Equal(t, http.StatusNotModified, w.Code)
+
+	w = write(http.Header{"If-Modified-Since": {updated.Add(-time.Hour).Format(http.TimeFormat)}})
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// ranges are ignored, feeds are always sent whole
+	w = write(http.Header{"Range": {"bytes=0-9"}})
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, full, w.Body.String())
+	assert.Empty(t, w.Header().Get("Content-Range"))
+
+	w = write(http.Header{"Range": {"bytes=0-9"}, "If-Range": {etag}})
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, full, w.Body.String())
+}
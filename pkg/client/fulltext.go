@@ -0,0 +1,81 @@
+package client
+
+import (
+	"fmt"
+	"html"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/go-shiori/go-readability"
+)
+
+// fullTextDefault reports whether articles are embedded in full when a feed does not ask, set with FULLTEXT.
+func fullTextDefault() bool {
+	return strings.ToLower(os.Getenv("FULLTEXT")) == "true"
+}
+
+// domainList parses a comma separated list of domains from the environment variable key.
+func domainList(key string) []string {
+	var domains []string
+	for _, domain := range strings.Split(os.Getenv(key), ",") {
+		domain = strings.ToLower(strings.TrimSpace(domain))
+		if domain != "" {
+			domains = append(domains, strings.TrimPrefix(domain, "."))
+		}
+	}
+	return domains
+}
+
+// matchDomain reports whether host is one of domains or a subdomain of one.
+func matchDomain(host string, domains []string) bool {
+	for _, domain := range domains {
+		if host == domain || strings.HasSuffix(host, "."+domain) {
+			return true
+		}
+	}
+	return false
+}
+
+// fullTextAllowed reports whether the page at pageURL may be fetched for its full text.
+// Domains in FULLTEXT_DENY are never fetched; when FULLTEXT_ALLOW is set, only its domains are.
+func fullTextAllowed(pageURL string) bool {
+	u, err := url.Parse(pageURL)
+	if err != nil {
+		return false
+	}
+	host := strings.ToLower(u.Hostname())
+
+	if matchDomain(host, domainList("FULLTEXT_DENY")) {
+		return false
+	}
+
+	allow := domainList("FULLTEXT_ALLOW")
+	return len(allow) == 0 || matchDomain(host, allow)
+}
+
+// fullTextElement renders the readable content of the page at pageURL, headed by its title and byline.
+func fullTextElement(pageURL string, article readability.Article) string {
+	var b strings.Builder
+
+	title := article.Title
+	if title == "" {
+		title = pageURL
+	}
+	fmt.Fprintf(&b, `<p><a href="%s"><strong>%s</strong></a>`, pageURL, html.EscapeString(title))
+
+	var source []string
+	if article.Byline != "" {
+		source = append(source, html.EscapeString(article.Byline))
+	}
+	if article.SiteName != "" {
+		source = append(source, html.EscapeString(article.SiteName))
+	}
+	if len(source) > 0 {
+		fmt.Fprintf(&b, `<br /><small>%s</small>`, strings.Join(source, " | "))
+	}
+	b.WriteString("</p>")
+
+	b.WriteString(article.Content)
+	return b.String()
+}
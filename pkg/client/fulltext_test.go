@@ -0,0 +1,60 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/cameronstanley/go-reddit"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFullTextAllowed(t *testing.T) {
+	cases := []struct {
+		url   string
+		allow string
+		deny  string
+		want  bool
+	}{
+		{"https://example.com/a", "", "", true},
+		{"https://example.com/a", "example.com", "", true},
+		{"https://EXAMPLE.com/a", "example.com", "", true},
+		{"https://news.example.com/a", "example.com", "", true},
+		{"https://evilexample.com/a", "example.com", "", false},
+		{"https://example.com.evil.net/a", "example.com", "", false},
+		{"https://other.org/a", "example.com, .other.org", "", true},
+		{"https://other.net/a", "example.com,other.org", "", false},
+		{"https://example.com/a", "", "example.com", false},
+		{"https://news.example.com/a", "", "example.com", false},
+		{"https://evilexample.com/a", "", "example.com", true},
+		// deny wins over allow
+		{"https://paywall.example.com/a", "example.com", "paywall.example.com", false},
+		{"https://example.com/a", "example.com", "example.com", false},
+		{"https://www.example.com/a", "example.com", "paywall.example.com", true},
+		{"://invalid", "", "", false},
+	}
+	for _, c := range cases {
+		t.Setenv("FULLTEXT_ALLOW", c.allow)
+		t.Setenv("FULLTEXT_DENY", c.deny)
+		assert.Equal(t, c.want, fullTextAllowed(c.url), "%s allow=%q deny=%q", c.url, c.allow, c.deny)
+	}
+}
+
+func TestFullTextFallback(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte(`<html><head><title>Not much</title></head><body></body></html>`))
+	}))
+	defer server.Close()
+
+	client := &RedditClient{HttpClient: server.Client()}
+	link := &reddit.Link{URL: server.URL + "/article"}
+	article, err := GetArticle(context.Background(), client, link, ArticleOptions{FullText: true})
+	require.NoError(t, err)
+
+	// the page is not readable, it gets a preview card
+	assert.Contains(t, *article, `<a href="`+server.URL+`/article" style="text-decoration:none;color:inherit">`)
+	assert.Contains(t, *article, `<div style="border:1px solid gray">`)
+}
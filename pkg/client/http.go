@@ -6,7 +6,6 @@ import (
 	"errors"
 	"fmt"
 	"html"
-	"io"
 	"log"
 	"net/http"
 	"net/url"
@@ -37,21 +36,6 @@ func knownTypes(m *mimetype.MIME) fileType {
 	return unknown
 }
 
-func getMimeType(client *http.Client, url string) (*mimetype.MIME, error) {
-	resp, err := client.Get(url)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	mime, err := mimetype.DetectReader(resp.Body)
-	if err != nil {
-		return nil, err
-	}
-
-	return mime, nil
-}
-
 func cleanupUrl(url string) (string, error) {
 	if strings.Contains(url, "imgur") && strings.HasSuffix(url, "gifv") {
 		return strings.ReplaceAll(url, "gifv", "webm"), nil
@@ -76,7 +60,8 @@ func imgElement(media gReddit.MediaMetadata) string {
 
 var ErrVideoMissingFromJSON = errors.New("video missing from json")
 
-func GetArticle(client *RedditClient, link *gReddit.Link) (*string, error) {
+// GetArticle renders the content of link. The linked site is requested within ctx.
+func GetArticle(ctx context.Context, client *RedditClient, link *gReddit.Link, opts ArticleOptions) (*string, error) {
 	u := link.URL
 	str := ""
 
@@ -128,13 +113,12 @@ func GetArticle(client *RedditClient, link *gReddit.Link) (*string, error) {
 
 	// todo clean up
 	if strings.Contains(u, "gfycat") {
-		res, err := client.HttpClient.Get(u)
+		page, err := fetchPage(ctx, client.HttpClient, u)
 		if err != nil {
 			return nil, err
 		}
 
-		defer res.Body.Close()
-		doc, err := goquery.NewDocumentFromReader(res.Body)
+		doc, err := goquery.NewDocumentFromReader(bytes.NewReader(page.body))
 		if err != nil {
 			return nil, err
 		}
@@ -170,7 +154,7 @@ func GetArticle(client *RedditClient, link *gReddit.Link) (*string, error) {
 		return nil, err
 	}
 
-	t, err := getMimeType(client.HttpClient, url)
+	t, err := getMimeType(ctx, client.HttpClient, url)
 	if err != nil {
 		return nil, err
 	}
@@ -191,8 +175,19 @@ func GetArticle(client *RedditClient, link *gReddit.Link) (*string, error) {
 		return &str, nil
 	}
 
-	res, err := linkpreview.Parse(url,
-		linkpreview.ReturnMetaTags(true))
+	if opts.FullText && fullTextAllowed(url) {
+		article, err := articleFromURL(ctx, client.HttpClient, url)
+		if err == nil && strings.TrimSpace(article.Content) != "" {
+			str += fullTextElement(url, article)
+			return &str, nil
+		}
+		// fall back to the preview card
+		if err != nil {
+			log.Printf("WARN: No full text for %s: %s", url, err.Error())
+		}
+	}
+
+	title, image, err := previewFromURL(url)
 	if err != nil {
 		log.Println("ERROR: Something went wrong while we are processing a link post.", err)
 		log.Println("Reference: ", url)
@@ -201,8 +196,8 @@ func GetArticle(client *RedditClient, link *gReddit.Link) (*string, error) {
 
 	previewImage := ""
 
-	if len(res.OGMeta.Images) > 0 {
-		previewImage = fmt.Sprintf(`<img src="%s" />`, res.OGMeta.Images[0].URL)
+	if image != "" {
+		previewImage = fmt.Sprintf(`<img src="%s" />`, image)
 	}
 
 	str += fmt.Sprintf(`<a href="%s" style="text-decoration:none;color:inherit">
@@ -212,7 +207,7 @@ func GetArticle(client *RedditClient, link *gReddit.Link) (*string, error) {
 			<span><strong>%s</strong></span><br />
 			<span><small>%s</small></span>
 		</div>
-	</div></a>`, url, previewImage, res.Title, strings.Split(url, "?")[0])
+	</div></a>`, url, previewImage, html.EscapeString(title), strings.Split(url, "?")[0])
 
 	return &str, nil
 
@@ -227,28 +222,20 @@ func articleFromURL(ctx context.Context, client *http.Client, pageURL string) (r
 	}
 
 	// Fetch page from URL
-	req, err := http.NewRequestWithContext(ctx, "GET", pageURL, nil)
-	if err != nil {
-		return readability.Article{}, fmt.Errorf("failed to create req for page: %v", err)
-	}
-	resp, err := client.Do(req)
+	page, err := fetchPage(ctx, client, pageURL)
 	if err != nil {
 		return readability.Article{}, fmt.Errorf("failed to fetch the page: %v", err)
 	}
-	defer resp.Body.Close()
 
 	// Make sure content type is HTML
-	cp := resp.Header.Get("Content-Type")
+	cp := page.header.Get("Content-Type")
 	if !strings.Contains(cp, "text/html") {
 		return readability.Article{}, fmt.Errorf("URL is not a HTML document")
 	}
 
 	// Check if the page is readable
-	var buffer bytes.Buffer
-	tee := io.TeeReader(resp.Body, &buffer)
-
 	parser := readability.NewParser()
-	if !parser.Check(tee) {
+	if !parser.Check(bytes.NewReader(page.body)) {
 		return readability.Article{}, fmt.Errorf("the page is not readable")
 	}
 
@@ -257,5 +244,37 @@ func articleFromURL(ctx context.Context, client *http.Client, pageURL string) (r
 		return readability.Article{}, fmt.Errorf("failed to parse URL: %v", err)
 	}
 
-	return parser.Parse(&buffer, parsedURL)
+	return parser.Parse(bytes.NewReader(page.body), parsedURL)
+}
+
+// previewFromURL returns the title and image of the page at pageURL, from its Open Graph tags when it has them.
+func previewFromURL(pageURL string) (title, image string, err error) {
+	res, err := linkpreview.Parse(pageURL,
+		linkpreview.ReturnMetaTags(true))
+	if err != nil {
+		return "", "", err
+	}
+
+	if len(res.OGMeta.Images) > 0 {
+		image = resolveURL(pageURL, res.OGMeta.Images[0].URL)
+	}
+	return res.Title, image, nil
+}
+
+// resolveURL resolves ref, which may be relative, against the page at pageURL.
+// It is empty when either url is invalid.
+func resolveURL(pageURL, ref string) string {
+	if ref == "" {
+		return ""
+	}
+
+	base, err := url.Parse(pageURL)
+	if err != nil {
+		return ""
+	}
+	u, err := url.Parse(ref)
+	if err != nil {
+		return ""
+	}
+	return base.ResolveReference(u).String()
 }
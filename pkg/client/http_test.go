@@ -0,0 +1,52 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/cameronstanley/go-reddit"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveURL(t *testing.T) {
+	tests := []struct {
+		ref  string
+		want string
+	}{
+		// og:image relative to the page
+		{"cover.png", "https://example.com/blog/cover.png"},
+		{"/static/cover.png", "https://example.com/static/cover.png"},
+		{"../cover.png", "https://example.com/cover.png"},
+		{"//cdn.example.com/cover.png", "https://cdn.example.com/cover.png"},
+		// or already absolute
+		{"http://img.example.org/cover.png", "http://img.example.org/cover.png"},
+		// no og:image, no image
+		{"", ""},
+		{"%zz", ""},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.want, resolveURL("https://example.com/blog/post?id=1", test.ref), test.ref)
+	}
+
+	assert.Empty(t, resolveURL("%zz", "cover.png"))
+}
+
+func TestPreviewCardWithoutImage(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte(`<html><head><title>No image</title></head><body></body></html>`))
+	}))
+	defer server.Close()
+
+	client := &RedditClient{HttpClient: server.Client()}
+	link := &reddit.Link{URL: server.URL + "/article"}
+	article, err := GetArticle(context.Background(), client, link, ArticleOptions{})
+	require.NoError(t, err)
+
+	// the card is kept, without an image
+	assert.Contains(t, *article, `<a href="`+server.URL+`/article" style="text-decoration:none;color:inherit">`)
+	assert.NotContains(t, *article, "<img")
+}
@@ -0,0 +1,201 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/cameronstanley/go-reddit"
+	"github.com/gorilla/feeds"
+)
+
+// messagePath matches the message boxes of the configured account. It is case sensitive like the route guarding them.
+var messagePath = regexp.MustCompile(`^\/message\/(inbox|unread|sent|mentions)$`)
+
+var messageBoxTitles = map[string]string{
+	"inbox":    "Inbox",
+	"unread":   "Unread messages",
+	"sent":     "Sent messages",
+	"mentions": "Username mentions",
+}
+
+// messageFeed builds a feed of a message box of the account client is authorized as.
+func messageFeed(redditURL string, client *RedditClient, r *http.Request) (*feeds.Feed, error) {
+	if client.Token == nil {
+		return nil, &feedError{http.StatusForbidden, "Messages need an account, see the OAUTH setup."}
+	}
+
+	box := messagePath.FindStringSubmatch(r.URL.Path)[1]
+	feed := &feeds.Feed{
+		Title:       fmt.Sprintf("Reddit: %s", messageBoxTitles[box]),
+		Link:        &feeds.Link{Href: fmt.Sprintf("%s/message/%s", frontendURL(), box)},
+		Description: fmt.Sprintf("%s of the account on Reddit", messageBoxTitles[box]),
+	}
+
+	err := addMessages(r.Context(), redditURL, client, box, feed)
+	if err != nil {
+		return nil, err
+	}
+
+	return feed, nil
+}
+
+// addMessages adds the messages of box to feed, one item per conversation.
+func addMessages(ctx context.Context, redditURL string, client *RedditClient, box string, feed *feeds.Feed) error {
+	api := client.apiClient(redditURL)
+
+	var messages []*reddit.Message
+	var err error
+	switch box {
+	case "unread":
+		messages, err = api.GetUnreadMessages(ctx)
+	case "sent":
+		messages, err = api.GetSentMessages(ctx)
+	case "mentions":
+		messages, err = api.GetMentionMessages(ctx)
+	default:
+		messages, err = api.GetInboxMessages(ctx)
+	}
+	if err != nil {
+		return redditError(messageBoxTitles[box], err)
+	}
+
+	// the replies of unread messages come along with them, read or not
+	for _, thread := range messageThreads(messages, box == "unread") {
+		feed.Items = append(feed.Items, threadToFeed(thread))
+	}
+
+	feed.Sort(func(a, b *feeds.Item) bool {
+		return a.Updated.After(b.Updated)
+	})
+
+	return nil
+}
+
+// messageThreads groups messages and their replies by the conversation they belong to, oldest message first.
+// Comment replies and mentions are not conversations, each of them stands alone. With unreadOnly, messages already read are left out.
+func messageThreads(messages []*reddit.Message, unreadOnly bool) [][]*reddit.Message {
+	var threads [][]*reddit.Message
+	index := make(map[string]int)
+	seen := make(map[string]bool)
+
+	var add func(messages []*reddit.Message)
+	add = func(messages []*reddit.Message) {
+		for _, message := range messages {
+			if seen[message.Name] {
+				continue
+			}
+			seen[message.Name] = true
+
+			if !unreadOnly || message.New {
+				key := message.FirstMessageName
+				if key == "" {
+					key = message.Name
+				}
+
+				if i, ok := index[key]; ok {
+					threads[i] = append(threads[i], message)
+				} else {
+					index[key] = len(threads)
+					threads = append(threads, []*reddit.Message{message})
+				}
+			}
+
+			add(message.Replies)
+		}
+	}
+	add(messages)
+
+	for _, thread := range threads {
+		sort.SliceStable(thread, func(i, j int) bool {
+			return thread[i].CreatedUtc < thread[j].CreatedUtc
+		})
+	}
+
+	return threads
+}
+
+// threadToFeed renders a conversation as a single item, dated by its latest message.
+func threadToFeed(thread []*reddit.Message) *feeds.Item {
+	first, last := thread[0], thread[len(thread)-1]
+
+	id := first.FirstMessageName
+	if id == "" {
+		id = first.Name
+	}
+
+	itemLink := fmt.Sprintf("%s/message/messages/%s", frontendURL(), strings.TrimPrefix(id, "t4_"))
+	if first.WasComment && first.Context != "" {
+		itemLink = fmt.Sprintf("%s%s", frontendURL(), first.Context)
+	}
+
+	title := strings.TrimPrefix(first.Subject, "re: ")
+	switch {
+	case first.WasComment && first.LinkTitle != "":
+		title = fmt.Sprintf("%s from /u/%s on %s", first.Subject, messageAuthor(first), first.LinkTitle)
+	case len(thread) > 1:
+		title = fmt.Sprintf("%s (%d messages)", title, len(thread))
+	default:
+		title = fmt.Sprintf("%s from /u/%s", title, messageAuthor(first))
+	}
+
+	var b strings.Builder
+	for i, message := range thread {
+		if i > 0 {
+			b.WriteString("<hr/>")
+		}
+		b.WriteString(messageEntry(message))
+	}
+
+	return &feeds.Item{
+		Title:       title,
+		Link:        &feeds.Link{Href: itemLink},
+		Description: last.Body,
+		Author:      &feeds.Author{Name: messageAuthor(last)},
+		Created:     time.Unix(int64(first.CreatedUtc), 0),
+		Updated:     time.Unix(int64(last.CreatedUtc), 0),
+		Id:          id,
+		Content:     b.String(),
+	}
+}
+
+// messageAuthor names who sent message, which is the subreddit for mod mail and notifications.
+func messageAuthor(message *reddit.Message) string {
+	if message.Author != "" {
+		return message.Author
+	}
+	if message.Subreddit != "" {
+		return fmt.Sprintf("r/%s", message.Subreddit)
+	}
+	return "reddit"
+}
+
+// messageEntry renders one message of a conversation, headed by who sent it to whom.
+func messageEntry(message *reddit.Message) string {
+	var b strings.Builder
+
+	// messages to a subreddit's moderators are sent to "#subreddit"
+	dest := message.Dest
+	if !strings.HasPrefix(dest, "#") {
+		dest = "/u/" + dest
+	}
+
+	fmt.Fprintf(&b, `<p><small>from /u/%s to %s on %s`,
+		html.EscapeString(messageAuthor(message)), html.EscapeString(dest),
+		time.Unix(int64(message.CreatedUtc), 0).UTC().Format("January 2, 2006 15:04"))
+	if message.Subreddit != "" {
+		fmt.Fprintf(&b, ` in r/%s`, message.Subreddit)
+	}
+	if message.Context != "" {
+		fmt.Fprintf(&b, ` | <a href="%s%s">context</a>`, frontendURL(), message.Context)
+	}
+	b.WriteString("</small></p>")
+
+	b.WriteString(html.UnescapeString(message.BodyHTML))
+	return b.String()
+}
@@ -0,0 +1,96 @@
+package client
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/cameronstanley/go-reddit"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/oauth2"
+)
+
+func TestMessagePathCase(t *testing.T) {
+	u := newUpstream(t, map[string]string{
+		"/message/inbox.json":  "test_data/inbox.json",
+		"/MESSAGE/inbox.json":  "test_data/inbox.json",
+		"/Message/unread.json": "test_data/inbox.json",
+	})
+	client := u.client()
+	client.Token = &oauth2.Token{AccessToken: "token"}
+
+	for _, path := range []string{"/MESSAGE/inbox", "/Message/unread", "/message/INBOX", "/MESSAGE"} {
+		w := serveFeedRequest(u, client, nil, httptest.NewRequest("GET", path, nil))
+		assert.Equal(t, http.StatusNotFound, w.Code, path)
+	}
+	assert.Zero(t, u.requests)
+
+	w := serveFeedRequest(u, client, nil, httptest.NewRequest("GET", "/message/inbox", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "Go 1.18 is released")
+	assert.Equal(t, "private, max-age=1800", w.Header().Get("Cache-Control"))
+}
+
+// threadNames lists the names of the messages of each thread.
+func threadNames(threads [][]*reddit.Message) [][]string {
+	var all [][]string
+	for _, thread := range threads {
+		var names []string
+		for _, message := range thread {
+			names = append(names, message.Name)
+		}
+		all = append(all, names)
+	}
+	return all
+}
+
+func TestMessageThreads(t *testing.T) {
+	hello := &reddit.Message{Name: "t4_hello", CreatedUtc: 100, New: false}
+	reply := &reddit.Message{Name: "t4_reply", FirstMessageName: "t4_hello", CreatedUtc: 300, New: true}
+	nested := &reddit.Message{Name: "t4_nested", FirstMessageName: "t4_hello", CreatedUtc: 200}
+	hello.Replies = reddit.MessageReplies{nested}
+	other := &reddit.Message{Name: "t4_other", CreatedUtc: 400, New: true}
+	comment := &reddit.Message{Name: "t1_comment", CreatedUtc: 250, WasComment: true}
+
+	// the latest reply is listed before the conversation it belongs to, and twice
+	messages := []*reddit.Message{reply, other, hello, comment, reply}
+
+	// replies, nested or not, go under the first message of their conversation, oldest first,
+	// conversations keep the order they showed up in
+	assert.Equal(t, [][]string{
+		{"t4_hello", "t4_nested", "t4_reply"},
+		{"t4_other"},
+		{"t1_comment"},
+	}, threadNames(messageThreads(messages, false)))
+
+	// only the unread messages are kept, the conversation of the unread reply is still grouped by its first message
+	threads := messageThreads(messages, true)
+	assert.Equal(t, [][]string{{"t4_reply"}, {"t4_other"}}, threadNames(threads))
+	assert.Equal(t, "t4_hello", threadToFeed(threads[0]).Id)
+}
+
+func TestUnreadMessages(t *testing.T) {
+	u := newUpstream(t, map[string]string{
+		"/message/inbox.json":  "test_data/inbox.json",
+		"/message/unread.json": "test_data/inbox.json",
+	})
+	client := u.client()
+	client.Token = &oauth2.Token{AccessToken: "token"}
+
+	// the inbox has the comment reply and the conversation
+	w := serveFeedRequest(u, client, nil, httptest.NewRequest("GET", "/message/inbox", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "comment reply")
+	assert.Contains(t, w.Body.String(), "Thanks!")
+	// the conversation was answered last, it comes first
+	assert.Less(t, strings.Index(w.Body.String(), "Thanks!"), strings.Index(w.Body.String(), "comment reply"))
+
+	// the conversation was read, only the comment reply is left
+	w = serveFeedRequest(u, client, nil, httptest.NewRequest("GET", "/message/unread", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "comment reply")
+	assert.NotContains(t, w.Body.String(), "Thanks!")
+	assert.NotContains(t, w.Body.String(), "Hello")
+}
@@ -0,0 +1,80 @@
+package client
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/gorilla/feeds"
+)
+
+// multiPath matches the listings of a user's multireddit.
+var multiPath = regexp.MustCompile(`(?i)^\/(user|u)\/([a-z0-9_-]+)\/m\/([a-z0-9_]+)(\/(hot|new|top|rising|controversial))?$`)
+
+// subredditAggregates are the subreddits made of posts from everywhere else.
+var subredditAggregates = map[string]bool{
+	"all":     true,
+	"popular": true,
+}
+
+// describeListing creates the empty feed of a link listing at path and reports whether the listing
+// combines several subreddits. A single subreddit is described by the details Reddit includes with
+// "sr_detail=1", combined subreddits and multireddits get a title and description of their own.
+func describeListing(path string, listing *linkListing) (*feeds.Feed, bool) {
+	// proper capitalization of subreddit names, as the path may use any
+	displayNames := make(map[string]string)
+	var subreddits []string
+	for _, child := range listing.Data.Children {
+		name := strings.ToLower(child.Data.Subreddit)
+		if _, ok := displayNames[name]; ok {
+			continue
+		}
+		displayNames[name] = child.Data.Subreddit
+		subreddits = append(subreddits, "r/"+child.Data.Subreddit)
+	}
+
+	if match := multiPath.FindStringSubmatch(path); match != nil {
+		owner, name := match[2], match[3]
+		return &feeds.Feed{
+			Title:       fmt.Sprintf("m/%s by u/%s", name, owner),
+			Link:        &feeds.Link{Href: fmt.Sprintf("https://www.reddit.com/user/%s/m/%s", owner, name)},
+			Description: fmt.Sprintf("Multireddit of u/%s with posts from %s", owner, strings.Join(subreddits, ", ")),
+		}, true
+	}
+
+	match := subredditPath.FindStringSubmatch(path)
+	if match == nil {
+		// not a listing we know, describe it by its path
+		return &feeds.Feed{
+			Title:       strings.TrimPrefix(path, "/"),
+			Link:        &feeds.Link{Href: "https://www.reddit.com" + path},
+			Description: fmt.Sprintf("Posts from %s", strings.Join(subreddits, ", ")),
+		}, true
+	}
+
+	names := strings.Split(match[1], "+")
+	if len(names) == 1 && !subredditAggregates[strings.ToLower(names[0])] && len(listing.Data.Children) > 0 {
+		// Subreddit about details are returned in each posts when included with "sr_details=1"
+		// Attempt to grab them from the first post
+		return srDetailsFeed(listing.Data.Children[0].Data.SRDetails), false
+	}
+
+	var titles []string
+	for i, name := range names {
+		if displayName, ok := displayNames[strings.ToLower(name)]; ok {
+			names[i] = displayName
+		}
+		titles = append(titles, "r/"+names[i])
+	}
+
+	description := fmt.Sprintf("Posts from %s", strings.Join(titles, ", "))
+	if len(names) == 1 {
+		description = fmt.Sprintf("Posts from %s, including %s", titles[0], strings.Join(subreddits, ", "))
+	}
+
+	return &feeds.Feed{
+		Title:       "r/" + strings.Join(names, "+"),
+		Link:        &feeds.Link{Href: fmt.Sprintf("https://www.reddit.com/r/%s", match[1])},
+		Description: description,
+	}, true
+}
@@ -0,0 +1,68 @@
+package client
+
+import (
+	"testing"
+
+	"github.com/cameronstanley/go-reddit"
+	"github.com/stretchr/testify/assert"
+)
+
+// listingOf returns a listing with a post of each subreddit.
+func listingOf(subreddits ...string) *linkListing {
+	listing := &linkListing{}
+	for _, subreddit := range subreddits {
+		listing.Data.Children = append(listing.Data.Children, linkListingChildren{Kind: "t3", Data: reddit.Link{Subreddit: subreddit}})
+	}
+	return listing
+}
+
+func TestDescribeMultireddit(t *testing.T) {
+	feed, combined := describeListing("/user/Alice/m/Programming/new", listingOf("golang", "rust", "golang"))
+	assert.True(t, combined)
+	assert.Equal(t, "m/Programming by u/Alice", feed.Title)
+	assert.Equal(t, "https://www.reddit.com/user/Alice/m/Programming", feed.Link.Href)
+	assert.Equal(t, "Multireddit of u/Alice with posts from r/golang, r/rust", feed.Description)
+}
+
+func TestDescribeCombinedSubreddits(t *testing.T) {
+	// names are written the way Reddit does, whatever the path
+	feed, combined := describeListing("/r/GOLANG+rust+zig/top", listingOf("golang", "Rust"))
+	assert.True(t, combined)
+	assert.Equal(t, "r/golang+Rust+zig", feed.Title)
+	assert.Equal(t, "https://www.reddit.com/r/GOLANG+rust+zig", feed.Link.Href)
+	assert.Equal(t, "Posts from r/golang, r/Rust, r/zig", feed.Description)
+
+	feed, combined = describeListing("/r/all", listingOf("AskReddit", "pics"))
+	assert.True(t, combined)
+	assert.Equal(t, "r/all", feed.Title)
+	assert.Equal(t, "Posts from r/all, including r/AskReddit, r/pics", feed.Description)
+}
+
+func TestDescribeSubreddit(t *testing.T) {
+	listing := listingOf("golang")
+	listing.Data.Children[0].Data.SRDetails = reddit.Subreddit{
+		Title:             "The Go Programming Language",
+		URL:               "/r/golang/",
+		PublicDescription: "Ask questions and post articles about Go",
+		CommunityIcon:     "https://styles.redditmedia.com/icon.png?width=256",
+	}
+
+	feed, combined := describeListing("/r/Golang/new", listing)
+	assert.False(t, combined)
+	assert.Equal(t, "The Go Programming Language", feed.Title)
+	assert.Equal(t, "https://www.reddit.com/r/golang/", feed.Link.Href)
+	assert.Equal(t, "Ask questions and post articles about Go", feed.Description)
+	assert.Equal(t, "https://styles.redditmedia.com/icon.png", feed.Image.Url)
+}
+
+func TestDescribeUnknownListing(t *testing.T) {
+	feed, combined := describeListing("/best", listingOf("golang", "rust"))
+	assert.True(t, combined)
+	assert.Equal(t, "best", feed.Title)
+	assert.Equal(t, "https://www.reddit.com/best", feed.Link.Href)
+	assert.Equal(t, "Posts from r/golang, r/rust", feed.Description)
+
+	// a single subreddit without posts has no details to tell
+	feed, _ = describeListing("/r/golang", listingOf())
+	assert.Equal(t, "r/golang", feed.Title)
+}
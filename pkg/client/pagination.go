@@ -0,0 +1,169 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"regexp"
+	"strconv"
+)
+
+// defaultMaxPages caps the listing pages fetched for a single feed when MAX_PAGES is not set.
+const defaultMaxPages = 5
+
+// maxPages is the most listing pages a feed may follow, set with MAX_PAGES.
+func maxPages() int {
+	max, err := strconv.Atoi(os.Getenv("MAX_PAGES"))
+	if err != nil || max < 1 {
+		return defaultMaxPages
+	}
+	return max
+}
+
+// pagination is how much of a listing a feed request asks for. With items set,
+// pages are followed until that many links pass the filters, within the page cap.
+type pagination struct {
+	pages int
+	items int
+}
+
+func newPagination(r *http.Request) pagination {
+	p := pagination{pages: 1}
+	max := maxPages()
+
+	if items, ok := queryInt(r, "items"); ok && items > 0 {
+		p.items = items
+		p.pages = max
+	}
+
+	if pages, ok := queryInt(r, "pages"); ok && pages > 0 {
+		p.pages = pages
+	}
+
+	if p.pages > max {
+		p.pages = max
+	}
+
+	return p
+}
+
+// fetchLinks requests a link listing from Reddit, following its "after" cursor for as many pages
+// as the request asks for. Links showing up on several pages are only kept once.
+func fetchLinks(redditURL string, client *RedditClient, filter linkFilter, r *http.Request, expected *regexp.Regexp, what string) (*linkListing, error) {
+	p := newPagination(r)
+
+	var result linkListing
+	seen := make(map[string]bool)
+	matched := 0
+
+	for page := 0; page < p.pages; page++ {
+		pageReq := r
+		if page > 0 {
+			pageReq = nextPage(r, result.Data.After, len(seen))
+		}
+
+		var listing linkListing
+		err := fetchJSON(redditURL, client, pageReq, expected, what, &listing)
+		if err != nil {
+			return nil, err
+		}
+
+		if page == 0 {
+			result.Kind = listing.Kind
+			result.Data.Modhash = listing.Data.Modhash
+			result.Data.Before = listing.Data.Before
+		}
+		result.Data.After = listing.Data.After
+
+		fresh := 0
+		for _, child := range listing.Data.Children {
+			if seen[child.Data.ID] {
+				continue
+			}
+			seen[child.Data.ID] = true
+			fresh++
+
+			// filtered links are kept, they still describe the listing
+			if filter.match(&child.Data) {
+				if p.items > 0 && matched >= p.items {
+					continue
+				}
+				matched++
+			}
+			result.Data.Children = append(result.Data.Children, child)
+		}
+
+		if result.Data.After == "" || fresh == 0 || (p.items > 0 && matched >= p.items) {
+			break
+		}
+	}
+
+	return &result, nil
+}
+
+// fetchThings is fetchLinks for the listings mixing links and comments, like a user's overview.
+func fetchThings(redditURL string, client *RedditClient, filter linkFilter, r *http.Request, expected *regexp.Regexp, what string) (*thingListing, error) {
+	p := newPagination(r)
+
+	var result thingListing
+	seen := make(map[string]bool)
+	matched := 0
+
+	for page := 0; page < p.pages; page++ {
+		pageReq := r
+		if page > 0 {
+			pageReq = nextPage(r, result.Data.After, len(seen))
+		}
+
+		var listing thingListing
+		err := fetchJSON(redditURL, client, pageReq, expected, what, &listing)
+		if err != nil {
+			return nil, err
+		}
+
+		if page == 0 {
+			result.Kind = listing.Kind
+		}
+		result.Data.After = listing.Data.After
+
+		fresh := 0
+		for _, child := range listing.Data.Children {
+			var thing struct {
+				Name string `json:"name"`
+			}
+			if err := json.Unmarshal(child.Data, &thing); err != nil {
+				return nil, fmt.Errorf("JSON: %w", err)
+			}
+			if seen[thing.Name] {
+				continue
+			}
+			seen[thing.Name] = true
+			fresh++
+
+			if filter.matchThing(child.Kind, child.Data) {
+				if p.items > 0 && matched >= p.items {
+					continue
+				}
+				matched++
+			}
+			result.Data.Children = append(result.Data.Children, child)
+		}
+
+		if result.Data.After == "" || fresh == 0 || (p.items > 0 && matched >= p.items) {
+			break
+		}
+	}
+
+	return &result, nil
+}
+
+// nextPage returns a copy of r asking for the listing page after the cursor after, count items having been seen.
+func nextPage(r *http.Request, after string, count int) *http.Request {
+	pageReq := r.Clone(r.Context())
+	q := pageReq.URL.Query()
+	q.Set("after", after)
+	q.Set("count", strconv.Itoa(count))
+	pageReq.URL.RawQuery = q.Encode()
+	return pageReq
+}
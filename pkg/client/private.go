@@ -0,0 +1,105 @@
+package client
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/gorilla/feeds"
+	"github.com/sorae42/ressdit/pkg/store"
+)
+
+// privateFeedTitles are the private feeds of an account, by their name in the feed url.
+var privateFeedTitles = map[string]string{
+	"front":   "Front page",
+	"saved":   "Saved",
+	"upvoted": "Upvoted",
+	"inbox":   "Inbox",
+	"unread":  "Unread messages",
+}
+
+// frontPagePath is where Reddit serves the front page of the authorized account.
+var frontPagePath = regexp.MustCompile(`(?i)^\/(hot)?$`)
+
+// PrivateHandler serves a private feed of the Reddit account username, named by the last segment of r.URL.
+// client must be authorized as username. seen may be nil when first seen times are not tracked.
+func PrivateHandler(redditURL string, now NowFn, client *RedditClient, getArticle GetArticleFn, seen *store.Store, username string, w http.ResponseWriter, r *http.Request) {
+	format, err := feedFormatFromRequest(r)
+	if err != nil {
+		writeError(w, now, err)
+		return
+	}
+
+	kind := strings.ToLower(path.Base(r.URL.Path))
+	title, ok := privateFeedTitles[kind]
+	if !ok {
+		http.Error(w, fmt.Sprintf("Unknown feed %s, expected front, saved, upvoted, inbox or unread.", kind), http.StatusNotFound)
+		return
+	}
+
+	// the feed token never leaves the handler, feeds are known by their account
+	r.URL.Path = fmt.Sprintf("/feeds/%s/%s", strings.ToLower(username), kind)
+	log.Printf("Fetch %s ", r.URL)
+	defer timer(r.URL.String())()
+
+	// shared caches must not keep them
+	w.Header().Set("Cache-Control", "private, max-age=1800")
+
+	serveFeed(w, r, now, seen, format, func(opts feedOptions) (*feeds.Feed, error) {
+		feed := &feeds.Feed{
+			Title:       fmt.Sprintf("u/%s: %s", username, title),
+			Link:        &feeds.Link{Href: frontendURL()},
+			Description: fmt.Sprintf("%s of u/%s on Reddit", title, username),
+		}
+
+		var err error
+		switch kind {
+		case "front":
+			err = frontPageFeed(redditURL, client, getArticle, opts, r, feed)
+		case "saved", "upvoted":
+			feed.Link.Href = fmt.Sprintf("%s/user/%s/%s", frontendURL(), username, kind)
+			err = historyFeed(redditURL, client, getArticle, opts, r, feed, username, kind)
+		default:
+			feed.Link.Href = fmt.Sprintf("%s/message/%s", frontendURL(), kind)
+			err = addMessages(r.Context(), redditURL, client, kind, feed)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		return feed, nil
+	})
+}
+
+// redditRequest returns a copy of r asking Reddit for the page at path, with the same query.
+func redditRequest(r *http.Request, path string) *http.Request {
+	rr := r.Clone(r.Context())
+	rr.URL.Path = path
+	return rr
+}
+
+func frontPageFeed(redditURL string, client *RedditClient, getArticle GetArticleFn, opts feedOptions, r *http.Request, feed *feeds.Feed) error {
+	result, err := fetchLinks(redditURL, client, opts.filter, redditRequest(r, "/hot"), frontPagePath, "Front page")
+	if err != nil {
+		return err
+	}
+
+	addLinks(r, feed, client, getArticle, opts, result.Data.Children, true)
+	return nil
+}
+
+// historyFeed adds the posts and comments the user saved or upvoted, as told by kind.
+func historyFeed(redditURL string, client *RedditClient, getArticle GetArticleFn, opts feedOptions, r *http.Request, feed *feeds.Feed, username string, kind string) error {
+	expected := regexp.MustCompile(fmt.Sprintf(`(?i)^\/user\/%s\/%s$`, regexp.QuoteMeta(username), kind))
+
+	var result thingListing
+	err := fetchJSON(redditURL, client, redditRequest(r, fmt.Sprintf("/user/%s/%s", username, kind)), expected, privateFeedTitles[kind], &result)
+	if err != nil {
+		return err
+	}
+
+	return addThings(r, feed, client, getArticle, opts, result)
+}
@@ -0,0 +1,157 @@
+package client
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cameronstanley/go-reddit"
+)
+
+// lowBudget is the number of remaining requests below which they are spread over the rest of the window.
+const lowBudget = 10
+
+// RateLimitError is returned for requests to Reddit that can't be made before the budget resets.
+type RateLimitError struct {
+	Reset time.Time
+}
+
+func (e *RateLimitError) Error() string {
+	return fmt.Sprintf("Rate limited by Reddit until %s.", e.Reset.Format(time.RFC3339))
+}
+
+// RetryAfter returns the number of seconds until the budget resets, as sent in a Retry-After header.
+func (e *RateLimitError) RetryAfter(now time.Time) int {
+	return max(1, int(math.Ceil(e.Reset.Sub(now).Seconds())))
+}
+
+// RateLimiter is a http.RoundTripper keeping requests to Reddit within the budget
+// reported by the X-Ratelimit-* headers of its responses. Requests to other hosts pass through.
+type RateLimiter struct {
+	transport http.RoundTripper
+	maxWait   time.Duration
+	now       func() time.Time
+
+	mu        sync.Mutex
+	remaining float64
+	used      float64
+	reset     time.Time
+}
+
+// NewRateLimiter wraps transport. Requests wait at most maxWait for the budget to reset,
+// when it is further away they fail with a *RateLimitError.
+func NewRateLimiter(transport http.RoundTripper, maxWait time.Duration, now func() time.Time) *RateLimiter {
+	return &RateLimiter{
+		transport: transport,
+		maxWait:   maxWait,
+		now:       now,
+	}
+}
+
+// Budget returns the number of requests left and when the budget resets. The reset time is zero until Reddit told.
+func (l *RateLimiter) Budget() (remaining float64, reset time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.remaining, l.reset
+}
+
+func (l *RateLimiter) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !isRedditHost(req.URL) {
+		return l.transport.RoundTrip(req)
+	}
+
+	delay, err := l.reserve()
+	if err != nil {
+		return nil, err
+	}
+	if delay > 0 {
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		}
+	}
+
+	resp, err := l.transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	l.update(resp)
+
+	if resp.StatusCode == http.StatusTooManyRequests {
+		resp.Body.Close()
+		l.mu.Lock()
+		reset := l.reset
+		l.mu.Unlock()
+		return nil, &RateLimitError{Reset: reset}
+	}
+
+	return resp, nil
+}
+
+// reserve takes a request from the budget and returns how long to wait before making it.
+func (l *RateLimiter) reserve() (time.Duration, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	if l.reset.IsZero() || !now.Before(l.reset) {
+		// new window, the next response tells what is left
+		return 0, nil
+	}
+	window := l.reset.Sub(now)
+
+	if l.remaining < 1 {
+		if window > l.maxWait {
+			return 0, &RateLimitError{Reset: l.reset}
+		}
+		return window, nil
+	}
+
+	l.remaining--
+	if l.remaining < lowBudget {
+		// spread what is left over the rest of the window
+		return min(window/time.Duration(l.remaining+1), l.maxWait), nil
+	}
+
+	return 0, nil
+}
+
+// update records the budget reported by resp.
+func (l *RateLimiter) update(resp *http.Response) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	remaining, remainingErr := strconv.ParseFloat(resp.Header.Get("X-Ratelimit-Remaining"), 64)
+	reset, resetErr := strconv.ParseFloat(resp.Header.Get("X-Ratelimit-Reset"), 64)
+	if used, err := strconv.ParseFloat(resp.Header.Get("X-Ratelimit-Used"), 64); err == nil {
+		l.used = used
+	}
+
+	if remainingErr == nil && resetErr == nil {
+		l.remaining = remaining
+		l.reset = now.Add(time.Duration(reset * float64(time.Second)))
+	}
+
+	if resp.StatusCode == http.StatusTooManyRequests {
+		l.remaining = 0
+		l.reset = reddit.RateLimitReset(resp.Header, now)
+		log.Printf("WARN: Rate limited by Reddit after %.0f requests, until %s", l.used, l.reset.Format(time.RFC3339))
+	}
+}
+
+func isRedditHost(u *url.URL) bool {
+	host := strings.ToLower(u.Hostname())
+	return host == "reddit.com" || strings.HasSuffix(host, ".reddit.com")
+}
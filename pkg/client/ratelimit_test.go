@@ -0,0 +1,137 @@
+package client
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// toServer sends the requests to any host to a test server.
+type toServer struct {
+	server *httptest.Server
+}
+
+func (t toServer) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.URL.Scheme = "http"
+	req.URL.Host = t.server.Listener.Addr().String()
+	return t.server.Client().Transport.RoundTrip(req)
+}
+
+// fakeReddit answers every request with the rate limit headers and status of its fields.
+type fakeReddit struct {
+	*httptest.Server
+	requests  int32
+	status    int32
+	remaining string
+	reset     string
+}
+
+func newFakeReddit(t *testing.T, remaining, reset string) *fakeReddit {
+	f := &fakeReddit{status: http.StatusOK, remaining: remaining, reset: reset}
+	f.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&f.requests, 1)
+		w.Header().Set("X-Ratelimit-Used", "1")
+		w.Header().Set("X-Ratelimit-Remaining", f.remaining)
+		w.Header().Set("X-Ratelimit-Reset", f.reset)
+		if status := int(atomic.LoadInt32(&f.status)); status != http.StatusOK {
+			w.Header().Set("Retry-After", "30")
+			http.Error(w, http.StatusText(status), status)
+			return
+		}
+		w.Write([]byte(`{}`))
+	}))
+	t.Cleanup(f.Close)
+	return f
+}
+
+func (f *fakeReddit) client(maxWait time.Duration, now NowFn) *http.Client {
+	return &http.Client{Transport: NewRateLimiter(toServer{f.Server}, maxWait, now)}
+}
+
+func TestRateLimiterBudget(t *testing.T) {
+	now := time.Now()
+	f := newFakeReddit(t, "42", "60")
+	limiter := NewRateLimiter(toServer{f.Server}, time.Second, func() time.Time { return now })
+
+	remaining, reset := limiter.Budget()
+	assert.Zero(t, remaining)
+	assert.True(t, reset.IsZero())
+
+	resp, err := (&http.Client{Transport: limiter}).Get("https://oauth.reddit.com/r/golang.json")
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	remaining, reset = limiter.Budget()
+	assert.Equal(t, 42.0, remaining)
+	assert.Equal(t, now.Add(time.Minute), reset)
+}
+
+func TestRateLimiterMaxWait(t *testing.T) {
+	now := time.Now()
+	f := newFakeReddit(t, "0", "60")
+	client := f.client(10*time.Second, func() time.Time { return now })
+
+	resp, err := client.Get("https://oauth.reddit.com/r/golang.json")
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	// the budget is spent and resets after maxWait, the request is not made
+	_, err = client.Get("https://oauth.reddit.com/r/golang.json")
+	var rl *RateLimitError
+	require.True(t, errors.As(err, &rl), "%v", err)
+	assert.Equal(t, now.Add(time.Minute), rl.Reset)
+	assert.Equal(t, 60, rl.RetryAfter(now))
+	assert.EqualValues(t, 1, atomic.LoadInt32(&f.requests))
+
+	// other hosts are not limited
+	resp, err = client.Get(f.URL)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.EqualValues(t, 2, atomic.LoadInt32(&f.requests))
+}
+
+func TestRateLimiterWait(t *testing.T) {
+	now := time.Now()
+	f := newFakeReddit(t, "0", "0.05")
+	client := f.client(10*time.Second, func() time.Time { return now })
+
+	resp, err := client.Get("https://oauth.reddit.com/r/golang.json")
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	// the budget resets within maxWait, the request waits for it
+	start := time.Now()
+	resp, err = client.Get("https://oauth.reddit.com/r/golang.json")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.GreaterOrEqual(t, time.Since(start), 50*time.Millisecond)
+	assert.EqualValues(t, 2, atomic.LoadInt32(&f.requests))
+}
+
+func TestRateLimited(t *testing.T) {
+	now := time.Now()
+	f := newFakeReddit(t, "", "")
+	f.status = http.StatusTooManyRequests
+	nowFn := func() time.Time { return now }
+	client := &RedditClient{HttpClient: f.client(10*time.Second, nowFn), UserAgent: "test"}
+
+	// Reddit rejects the request, the feed reader is told when to come back
+	w := httptest.NewRecorder()
+	RssHandler("https://oauth.reddit.com", nowFn, client, testArticle, nil, w, httptest.NewRequest("GET", "/r/golang", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Equal(t, "30", w.Header().Get("Retry-After"))
+
+	// and the next feeds are answered without asking Reddit
+	w = httptest.NewRecorder()
+	RssHandler("https://oauth.reddit.com", nowFn, client, testArticle, nil, w, httptest.NewRequest("GET", "/r/rust", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Equal(t, "30", w.Header().Get("Retry-After"))
+	assert.EqualValues(t, 1, atomic.LoadInt32(&f.requests))
+}
@@ -3,8 +3,8 @@ package client
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
 	"log"
 	"net/http"
 	"net/url"
@@ -18,6 +18,9 @@ import (
 	"github.com/cameronstanley/go-reddit"
 	"github.com/gorilla/feeds"
 	"github.com/graph-gophers/dataloader"
+	"github.com/sorae42/ressdit/pkg/cache"
+	"github.com/sorae42/ressdit/pkg/filter"
+	"github.com/sorae42/ressdit/pkg/store"
 	"golang.org/x/oauth2"
 )
 
@@ -42,75 +45,241 @@ type RedditClient struct {
 	HttpClient *http.Client
 	UserAgent  string
 	Token      *oauth2.Token
+	// Cache keeps the listings fetched from Reddit for ListingTTL, so feeds of the same listing share them. Nil disables it.
+	Cache      cache.Cache
+	ListingTTL time.Duration
 }
 
-type GetArticleFn = func(client *RedditClient, link *reddit.Link) (*string, error)
+// apiRetries is how many times requests to Reddit are retried after a server error or a broken connection.
+const apiRetries = 1
+
+// apiLogger reports the retries of requests to Reddit.
+var apiLogger = log.New(log.Writer(), "WARN: ", log.LstdFlags|log.Lmsgprefix)
+
+// apiClient returns a reddit.Client sending its requests to redditURL through c, authorized with c.Token when there is one.
+func (c *RedditClient) apiClient(redditURL string) *reddit.Client {
+	httpClient := c.HttpClient
+	if c.Token != nil {
+		httpClient = &http.Client{
+			Transport: &oauth2.Transport{
+				Source: oauth2.StaticTokenSource(c.Token),
+				Base:   c.HttpClient.Transport,
+			},
+		}
+	}
+	return reddit.New(
+		reddit.WithBaseURL(redditURL),
+		reddit.WithAuthBaseURL(redditURL),
+		reddit.WithHTTPClient(httpClient),
+		reddit.WithUserAgent(c.UserAgent),
+		reddit.WithRetries(apiRetries),
+		reddit.WithLogger(apiLogger),
+	)
+}
+
+// ArticleOptions are the per request settings of article rendering.
+type ArticleOptions struct {
+	// FullText embeds the readable content of linked pages instead of a preview card.
+	FullText bool
+}
+
+type GetArticleFn = func(ctx context.Context, client *RedditClient, link *reddit.Link, opts ArticleOptions) (*string, error)
 type NowFn = func() time.Time
 
-func RssHandler(redditURL string, now NowFn, client *RedditClient, getArticle GetArticleFn, w http.ResponseWriter, r *http.Request) {
-	ctx := r.Context()
+// subredditPath matches the listings of a subreddit, or of several subreddits combined with "+".
+var subredditPath = regexp.MustCompile(`(?i)^\/r\/([a-z0-9_]+(\+[a-z0-9_]+)*)(\/(hot|new|top|rising|controversial))?$`)
 
+// RssHandler serves the feed of the Reddit page at r.URL. seen may be nil when first seen times are not tracked.
+func RssHandler(redditURL string, now NowFn, client *RedditClient, getArticle GetArticleFn, seen *store.Store, w http.ResponseWriter, r *http.Request) {
 	log.Printf("Fetch %s ", r.URL)
 	defer timer(r.URL.String())()
 
-	urlPath := strings.Split(r.URL.Path, "?")[0]
+	format, err := feedFormatFromRequest(r)
+	if err != nil {
+		writeError(w, now, err)
+		return
+	}
 
-	if strings.Contains(urlPath, ".json") {
+	if strings.HasSuffix(r.URL.Path, ".json") {
 		log.Println("WARN: Appending .json to url path is deprecated. This will likely to be removed in the future.")
-	} else {
-		r.URL.Path += ".json"
+		r.URL.Path = strings.TrimSuffix(r.URL.Path, ".json")
 	}
+	r.URL.Path = strings.TrimSuffix(r.URL.Path, "/")
 
-	url := fmt.Sprintf("%s%s", redditURL, r.URL)
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		http.Error(w, err.Error(), 500)
+	// the mux guards /message/ only as written, other spellings must not reach the account messages
+	if !messagePath.MatchString(r.URL.Path) && strings.HasPrefix(strings.ToLower(r.URL.Path)+"/", "/message/") {
+		http.NotFound(w, r)
 		return
 	}
 
-	req.Header.Add("User-Agent", client.UserAgent)
-	if client.Token != nil {
-		req.Header.Set("Authorization", fmt.Sprintf("bearer %s", client.Token.AccessToken))
+	if messagePath.MatchString(r.URL.Path) {
+		// shared caches must not keep the messages of the account
+		w.Header().Set("Cache-Control", "private, max-age=1800")
 	}
 
-	q := req.URL.Query()
-	q.Add("sr_detail", "1")
-	req.URL.RawQuery = q.Encode()
+	serveFeed(w, r, now, seen, format, func(opts feedOptions) (*feeds.Feed, error) {
+		switch {
+		case commentsPath.MatchString(r.URL.Path):
+			return commentsFeed(redditURL, client, opts, r)
+		case searchPath.MatchString(r.URL.Path):
+			return searchFeed(redditURL, client, getArticle, opts, r)
+		case userPath.MatchString(r.URL.Path):
+			return userFeed(redditURL, client, getArticle, opts, r)
+		case messagePath.MatchString(r.URL.Path):
+			return messageFeed(redditURL, client, r)
+		default:
+			return subredditFeed(redditURL, client, getArticle, opts, r)
+		}
+	})
+}
 
-	resp, err := client.HttpClient.Do(req)
+// serveFeed builds the feed of r with route and writes it in format. seen may be nil when first seen times are not tracked.
+func serveFeed(w http.ResponseWriter, r *http.Request, now NowFn, seen *store.Store, format feedFormat, route func(opts feedOptions) (*feeds.Feed, error)) {
+	opts, err := newFeedOptions(r)
 	if err != nil {
-		http.Error(w, err.Error(), 500)
-		log.Printf("ERROR: HTTP: %s", err.Error())
+		writeError(w, now, err)
 		return
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode == http.StatusForbidden {
-		http.Error(w, "Subreddit is private.", resp.StatusCode)
-		log.Printf("ERROR: Subreddit is private.")
+	feed, err := route(opts)
+	if err != nil {
+		writeError(w, now, err)
 		return
 	}
 
+	if seen != nil {
+		err = applySeen(seen, now, r, opts.onlyNew, feed)
+		if err != nil {
+			log.Printf("ERROR: Unable to track seen items: %s", err.Error())
+		}
+	}
+
+	writeFeed(w, r, feed, format)
+}
+
+// feedError is an error that is reported to the feed reader with its own status code.
+type feedError struct {
+	status int
+	msg    string
+}
+
+func (e *feedError) Error() string {
+	return e.msg
+}
+
+func writeError(w http.ResponseWriter, now NowFn, err error) {
+	status := 500
+	var fe *feedError
+	var rl *RateLimitError
+	if errors.As(err, &fe) {
+		status = fe.status
+	} else if errors.As(err, &rl) {
+		status = http.StatusServiceUnavailable
+		w.Header().Set("Retry-After", strconv.Itoa(rl.RetryAfter(now())))
+	}
+
+	http.Error(w, err.Error(), status)
+	log.Printf("ERROR: %s", err.Error())
+}
+
+// redditError turns an error of the Reddit API about what into one reported to the feed reader with a matching status code.
+// Other errors are returned as is.
+func redditError(what string, err error) error {
+	var apiErr *reddit.Error
+	if !errors.As(err, &apiErr) {
+		return err
+	}
+
+	switch {
+	case errors.Is(err, reddit.ErrRateLimited):
+		return &RateLimitError{Reset: apiErr.Reset}
+	case errors.Is(err, reddit.ErrPrivate):
+		return &feedError{http.StatusForbidden, fmt.Sprintf("%s is private.", what)}
+	case errors.Is(err, reddit.ErrQuarantined):
+		return &feedError{http.StatusForbidden, fmt.Sprintf("%s is quarantined.", what)}
+	case errors.Is(err, reddit.ErrForbidden):
+		return &feedError{http.StatusForbidden, fmt.Sprintf("%s is forbidden.", what)}
+	case errors.Is(err, reddit.ErrBanned):
+		return &feedError{http.StatusGone, fmt.Sprintf("%s is banned.", what)}
+	case errors.Is(err, reddit.ErrNotFound):
+		return &feedError{http.StatusNotFound, fmt.Sprintf("%s not found.", what)}
+	default:
+		return &feedError{http.StatusBadGateway, apiErr.Error()}
+	}
+}
+
+// fetchJSON requests the JSON version of the page at r.URL from Reddit and decodes it into v.
+// Reddit redirects unknown pages elsewhere, so the final path must still match expected.
+// what names the requested thing in error messages.
+func fetchJSON(redditURL string, client *RedditClient, r *http.Request, expected *regexp.Regexp, what string, v interface{}) error {
+	u := *r.URL
+	u.Path += ".json"
+	q := u.Query()
+	// the parameters of the feed are none of Reddit's business
+	for _, param := range feedParams {
+		q.Del(param)
+	}
+	q.Add("sr_detail", "1")
+	u.RawQuery = q.Encode()
+
+	key := listingKey(redditURL, &u)
+	if client.Cache != nil && !refreshing(r) {
+		if body, ok := client.Cache.Get(key); ok {
+			return decodeJSON(body, v)
+		}
+	}
+
+	api := client.apiClient(redditURL)
+	req, err := api.NewRequest(r.Context(), "GET", u.String(), nil)
+	if err != nil {
+		return err
+	}
+
+	var body json.RawMessage
+	resp, err := api.Do(req, &body)
+	if err != nil {
+		return redditError(what, err)
+	}
+
 	// strict check - return 404 instead of being redirected by Reddit.
-	if match, err := regexp.MatchString(`(?i)^\/r\/[a-z]+\.json$`, resp.Request.URL.Path); err != nil || !match {
-		http.Error(w, "Subreddit not found.", http.StatusNotFound)
-		log.Println("ERROR: Subreddit not found")
-		return
+	if !expected.MatchString(strings.TrimSuffix(resp.Request.URL.Path, ".json")) {
+		return &feedError{http.StatusNotFound, fmt.Sprintf("%s not found.", what)}
+	}
+
+	if client.Cache != nil {
+		client.Cache.Set(key, body, client.ListingTTL)
 	}
 
-	var result linkListing
-	err = json.NewDecoder(resp.Body).Decode(&result)
+	return decodeJSON(body, v)
+}
+
+func decodeJSON(body []byte, v interface{}) error {
+	err := json.Unmarshal(body, v)
 	if err != nil {
-		http.Error(w, err.Error(), 500)
-		log.Printf("ERROR: JSON: %s", err.Error())
-		return
+		return fmt.Errorf("JSON: %w", err)
+	}
+
+	return nil
+}
+
+// queryInt returns the integer value of the query parameter key, if it is present and valid.
+func queryInt(r *http.Request, key string) (int, bool) {
+	str, ok := r.URL.Query()[key]
+	if !ok {
+		return 0, false
+	}
+
+	value, err := strconv.Atoi(str[0])
+	if err != nil {
+		return 0, false
 	}
 
-	// Subreddit about details are returned in each posts when included with "sr_details=1"
-	// Attempt to grab them from the first post
-	sr_details := result.Data.Children[0].Data.SRDetails
+	return value, true
+}
 
-	feed := &feeds.Feed{
+// srDetailsFeed creates an empty feed described by the subreddit details Reddit includes with "sr_detail=1".
+func srDetailsFeed(sr_details reddit.Subreddit) *feeds.Feed {
+	return &feeds.Feed{
 		Title:       sr_details.Title,
 		Link:        &feeds.Link{Href: fmt.Sprintf("https://www.reddit.com%s", sr_details.URL)},
 		Description: sr_details.PublicDescription,
@@ -120,76 +289,188 @@ func RssHandler(redditURL string, now NowFn, client *RedditClient, getArticle Ge
 			Link:  fmt.Sprintf("https://www.reddit.com%s", sr_details.URL),
 		},
 	}
+}
 
-	var limit int
-	limitStr, scoreLimit := r.URL.Query()["scoreLimit"]
-	if scoreLimit {
-		limit, err = strconv.Atoi(limitStr[0])
-		if err != nil {
-			scoreLimit = false
-		}
+func subredditFeed(redditURL string, client *RedditClient, getArticle GetArticleFn, opts feedOptions, r *http.Request) (*feeds.Feed, error) {
+	expected, what := subredditPath, "Subreddit"
+	if multiPath.MatchString(r.URL.Path) {
+		expected, what = multiPath, "Multireddit"
 	}
 
-	var safe bool
-	safeStr, hasSafe := r.URL.Query()["safe"]
-	if hasSafe {
-		safe = strings.ToLower(safeStr[0]) == "true"
+	result, err := fetchLinks(redditURL, client, opts.filter, r, expected, what)
+	if err != nil {
+		return nil, err
 	}
 
-	var flair string
-	flairStr, hasFlair := r.URL.Query()["flair"]
-	if hasFlair {
-		flair = flairStr[0]
+	if len(result.Data.Children) == 0 {
+		return nil, &feedError{http.StatusNotFound, fmt.Sprintf("%s has no posts.", what)}
 	}
 
-	loader := articleLoader(client, getArticle)
-	var thunks []dataloader.Thunk
-	for _, link := range result.Data.Children {
-		if hasSafe && safe && (link.Data.Over18 || strings.ToLower(link.Data.LinkFlairText) == "nsfw") {
-			continue
-		}
+	feed, combined := describeListing(r.URL.Path, result)
+	addLinks(r, feed, client, getArticle, opts, result.Data.Children, combined)
 
-		if scoreLimit && limit > link.Data.Score {
-			continue
-		}
+	return feed, nil
+}
 
-		if hasFlair && flair != "" && link.Data.LinkFlairText != flair {
+// addLinks renders the links that pass the requested filters as items of feed, or as digests of them.
+// With labelled set, each item title is prefixed with the subreddit of its link.
+func addLinks(r *http.Request, feed *feeds.Feed, client *RedditClient, getArticle GetArticleFn, opts feedOptions, children []linkListingChildren, labelled bool) {
+	ctx := r.Context()
+	filter := opts.filter
+
+	if opts.digest.period != "" {
+		addDigests(r, feed, client, getArticle, opts, children)
+		return
+	}
+
+	loader := articleLoader(client, getArticle, opts.article)
+	var links []reddit.Link
+	var thunks []dataloader.Thunk
+	for _, link := range children {
+		if !filter.match(&link.Data) {
 			continue
 		}
 
+		links = append(links, link.Data)
 		thunks = append(thunks, loader.Load(ctx, dataKey(link.Data)))
 	}
 
-	for _, thunk := range thunks {
+	for i, thunk := range thunks {
 		val, err := thunk()
 		if err != nil {
 			continue
 		}
 
 		item := val.(*feeds.Item)
+		if labelled {
+			// label the item with its subreddit so mixed feeds stay readable
+			label := *item
+			label.Title = fmt.Sprintf("[r/%s] %s", links[i].Subreddit, item.Title)
+			item = &label
+		}
 		feed.Items = append(feed.Items, item)
 	}
+}
 
-	rss, err := feed.ToRss()
+// feedOptions are the query parameters shaping a feed, parsed once per request.
+type feedOptions struct {
+	filter  linkFilter
+	digest  digestOptions
+	article ArticleOptions
+	// onlyNew is the window of "?onlyNew=", zero when every item is kept
+	onlyNew time.Duration
+}
+
+func newFeedOptions(r *http.Request) (feedOptions, error) {
+	var opts feedOptions
+	var err error
+
+	opts.filter, err = newLinkFilter(r)
+	if err != nil {
+		return opts, err
+	}
+
+	opts.digest, err = newDigestOptions(r)
+	if err != nil {
+		return opts, err
+	}
+
+	opts.onlyNew, err = onlyNewWindow(r)
 	if err != nil {
-		http.Error(w, err.Error(), 500)
+		return opts, err
 	}
 
-	w.Header().Set("Content-Type", "application/rss+xml")
-	w.Header().Set("Cache-Control", "public, maxage=1800")
-	io.WriteString(w, rss)
+	opts.article.FullText = fullTextDefault()
+	if value := r.URL.Query().Get("fulltext"); value != "" {
+		opts.article.FullText = strings.ToLower(value) == "true"
+	}
+
+	return opts, nil
 }
 
-func linkToFeed(client *RedditClient, getArticle GetArticleFn, link *reddit.Link) *feeds.Item {
-	var content string
-	c, _ := getArticle(client, link)
-	if c != nil {
-		content = *c
+// linkFilter holds the filters requested through query parameters.
+type linkFilter struct {
+	scoreLimit bool
+	limit      int
+	safe       bool
+	flair      string
+	expr       *filter.Filter
+}
+
+func newLinkFilter(r *http.Request) (linkFilter, error) {
+	var filter linkFilter
+	filter.limit, filter.scoreLimit = queryInt(r, "scoreLimit")
+
+	safeStr, hasSafe := r.URL.Query()["safe"]
+	if hasSafe {
+		filter.safe = strings.ToLower(safeStr[0]) == "true"
 	}
+
+	flairStr, hasFlair := r.URL.Query()["flair"]
+	if hasFlair {
+		filter.flair = flairStr[0]
+	}
+
+	if source := r.URL.Query().Get("filter"); source != "" {
+		expr, err := parseFilter(source)
+		if err != nil {
+			return filter, err
+		}
+		filter.expr = expr
+	}
+
+	return filter, nil
+}
+
+// parseFilter compiles a filter expression, pointing at the failing position when it is invalid.
+func parseFilter(source string) (*filter.Filter, error) {
+	expr, err := filter.Parse(source)
+	var syntaxErr *filter.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		msg := fmt.Sprintf("Invalid filter at %s\n%s\n%s^", syntaxErr.Error(), source, strings.Repeat(" ", syntaxErr.Pos))
+		return nil, &feedError{http.StatusBadRequest, msg}
+	}
+
+	return expr, err
+}
+
+// match reports whether link passes every filter.
+func (f linkFilter) match(link *reddit.Link) bool {
+	if f.safe && (link.Over18 || strings.ToLower(link.LinkFlairText) == "nsfw") {
+		return false
+	}
+
+	if f.scoreLimit && f.limit > link.Score {
+		return false
+	}
+
+	if f.flair != "" && link.LinkFlairText != f.flair {
+		return false
+	}
+
+	if f.expr != nil && !f.expr.Match(link) {
+		return false
+	}
+
+	return true
+}
+
+// frontendURL is the Reddit interface feed items link to.
+func frontendURL() string {
 	redditUrl := os.Getenv("REDDIT_URL")
 	if redditUrl == "" {
 		redditUrl = "https://www.reddit.com"
 	}
+	return redditUrl
+}
+
+func linkToFeed(ctx context.Context, client *RedditClient, getArticle GetArticleFn, opts ArticleOptions, link *reddit.Link) *feeds.Item {
+	var content string
+	c, _ := getArticle(ctx, client, link, opts)
+	if c != nil {
+		content = *c
+	}
+	redditUrl := frontendURL()
 	author := link.Author
 	u, err := url.Parse(link.URL)
 	if err == nil {
@@ -218,7 +499,7 @@ func (k dataKey) String() string {
 
 func (k dataKey) Raw() interface{} { return k }
 
-func articleLoader(client *RedditClient, getArticle GetArticleFn) *dataloader.Loader {
+func articleLoader(client *RedditClient, getArticle GetArticleFn, opts ArticleOptions) *dataloader.Loader {
 	return dataloader.NewBatchedLoader(func(ctx context.Context, keys dataloader.Keys) []*dataloader.Result {
 		wg := &sync.WaitGroup{}
 		lock := &sync.Mutex{}
@@ -231,7 +512,7 @@ func articleLoader(client *RedditClient, getArticle GetArticleFn) *dataloader.Lo
 			go func(lock *sync.Mutex, wg *sync.WaitGroup, l reddit.Link) {
 				defer wg.Done()
 
-				item := linkToFeed(client, getArticle, &l)
+				item := linkToFeed(ctx, client, getArticle, opts, &l)
 
 				lock.Lock()
 				defer lock.Unlock()
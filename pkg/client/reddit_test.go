@@ -0,0 +1,55 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/cameronstanley/go-reddit"
+	"github.com/sorae42/ressdit/pkg/store"
+)
+
+// upstream is a fake Reddit answering the paths of fixtures with the content of their files.
+type upstream struct {
+	*httptest.Server
+	requests int32
+}
+
+func newUpstream(t *testing.T, fixtures map[string]string) *upstream {
+	u := &upstream{}
+	u.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&u.requests, 1)
+		file, ok := fixtures[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		b, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write(b)
+	}))
+	t.Cleanup(u.Close)
+	return u
+}
+
+func (u *upstream) client() *RedditClient {
+	return &RedditClient{HttpClient: u.Client(), UserAgent: "test"}
+}
+
+func testArticle(_ context.Context, _ *RedditClient, link *reddit.Link, _ ArticleOptions) (*string, error) {
+	article := "<p>" + link.ID + "</p>"
+	return &article, nil
+}
+
+// serveFeedRequest serves req with RssHandler, reading Reddit from u.
+func serveFeedRequest(u *upstream, client *RedditClient, seen *store.Store, req *http.Request) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	RssHandler(u.URL, time.Now, client, testArticle, seen, w, req)
+	return w
+}
@@ -0,0 +1,246 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/sorae42/ressdit/pkg/cache"
+)
+
+const (
+	// minRefreshHits is how many reads a feed needs between two refresh rounds to be refreshed in the background.
+	minRefreshHits = 2
+	// refreshReserve is the number of requests to Reddit left to readers, the refresher does not spend them.
+	refreshReserve = 50
+	// refreshTimeout bounds the time spent refreshing a single feed.
+	refreshTimeout = time.Minute
+	// staleTTL is how long the last good version of a feed is kept to be served when Reddit fails.
+	staleTTL = 24 * time.Hour
+	// staleWarning marks the feeds served from their last good version.
+	staleWarning = `110 - "Response is Stale"`
+)
+
+// Refresher renders popular feeds again before their cached version expires, so their readers never
+// wait for Reddit, and serves the last good version of feeds when Reddit fails.
+type Refresher struct {
+	cache   cache.Cache
+	ttl     time.Duration
+	limiter *RateLimiter
+	workers int
+	now     NowFn
+
+	mu    sync.Mutex
+	feeds map[string]*feedStats
+}
+
+// feedStats is what the refresher knows of a feed.
+type feedStats struct {
+	hits       int
+	rendered   time.Time
+	refreshing bool
+}
+
+// staleFeed is the last good version of a feed.
+type staleFeed struct {
+	Header http.Header `json:"header"`
+	Body   []byte      `json:"body"`
+}
+
+// NewRefresher creates a Refresher for feeds cached in c for ttl. It refreshes with up to workers feeds
+// at once, within the budget of limiter.
+func NewRefresher(c cache.Cache, ttl time.Duration, limiter *RateLimiter, workers int, now NowFn) *Refresher {
+	return &Refresher{
+		cache:   c,
+		ttl:     ttl,
+		limiter: limiter,
+		workers: workers,
+		now:     now,
+		feeds:   make(map[string]*feedStats),
+	}
+}
+
+// Track counts the reads of feeds through next, which must have normalized urls. Reads asking for a refresh are not counted.
+func (f *Refresher) Track(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet && !refreshing(r) {
+			f.mu.Lock()
+			f.stats(feedKey(r)).hits++
+			f.mu.Unlock()
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Render keeps the last good version of the feeds rendered by next. It goes between the cache and the feed handler.
+func (f *Refresher) Render(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		resp := &bufferedResponse{header: make(http.Header), status: http.StatusOK}
+		next.ServeHTTP(resp, r)
+
+		if resp.status == http.StatusOK {
+			key := feedKey(r)
+			f.mu.Lock()
+			f.stats(key).rendered = f.now()
+			f.mu.Unlock()
+
+			b, err := json.Marshal(staleFeed{Header: resp.header, Body: resp.body.Bytes()})
+			if err == nil {
+				f.cache.Set("stale:"+key, b, staleTTL)
+			}
+		}
+
+		resp.writeTo(w)
+	})
+}
+
+// ServeStale answers with the last good version of a feed, and a Warning header, when next fails with a server error.
+// It goes in front of the cache, which must not keep stale feeds: they are rendered again as soon as Reddit answers.
+func (f *Refresher) ServeStale(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		resp := &bufferedResponse{header: make(http.Header), status: http.StatusOK}
+		next.ServeHTTP(resp, r)
+
+		if resp.status >= 500 {
+			key := feedKey(r)
+			var stale staleFeed
+			if b, ok := f.cache.Get("stale:" + key); ok && json.Unmarshal(b, &stale) == nil {
+				log.Printf("WARN: Serving the last good version of %s", key)
+				for k, values := range stale.Header {
+					w.Header()[k] = values
+				}
+				w.Header().Set("Warning", staleWarning)
+				w.Write(stale.Body)
+				return
+			}
+		}
+
+		resp.writeTo(w)
+	})
+}
+
+// Run refreshes the popular feeds due to expire every interval through handler, until stop is closed.
+// handler is the whole chain given to Track, so the refreshed feeds replace the cached ones.
+// Feeds failing to refresh are dropped from the cache and rendered again by their next reader.
+func (f *Refresher) Run(handler http.Handler, interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			f.refresh(handler, f.due(interval))
+		}
+	}
+}
+
+// due returns the feeds to refresh this round, the most read first, as many as the rate limit budget allows.
+// Feeds are due when they would expire before the next round is over.
+func (f *Refresher) due(interval time.Duration) []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	now := f.now()
+	var keys []string
+	for key, stats := range f.feeds {
+		if stats.hits >= minRefreshHits && !stats.refreshing && !stats.rendered.IsZero() && now.Add(2*interval).After(stats.rendered.Add(f.ttl)) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return f.feeds[keys[i]].hits > f.feeds[keys[j]].hits
+	})
+
+	// a feed takes a request at least, readers keep refreshReserve of them
+	if remaining, reset := f.limiter.Budget(); now.Before(reset) {
+		keys = keys[:min(len(keys), max(0, int(remaining)-refreshReserve))]
+	}
+
+	for key, stats := range f.feeds {
+		// reads count less every round, feeds nobody reads anymore are forgotten
+		stats.hits /= 2
+		if stats.hits == 0 && now.Sub(stats.rendered) > f.ttl {
+			delete(f.feeds, key)
+		}
+	}
+	for _, key := range keys {
+		f.feeds[key].refreshing = true
+	}
+
+	return keys
+}
+
+// refresh renders the feeds at keys through handler, workers at a time.
+func (f *Refresher) refresh(handler http.Handler, keys []string) {
+	jobs := make(chan string)
+	var wg sync.WaitGroup
+	for i := 0; i < f.workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for key := range jobs {
+				f.refreshFeed(handler, key)
+			}
+		}()
+	}
+
+	for _, key := range keys {
+		jobs <- key
+	}
+	close(jobs)
+	wg.Wait()
+}
+
+func (f *Refresher) refreshFeed(handler http.Handler, key string) {
+	defer func() {
+		f.mu.Lock()
+		if stats, ok := f.feeds[key]; ok {
+			stats.refreshing = false
+		}
+		f.mu.Unlock()
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), refreshTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, key, nil)
+	if err != nil {
+		log.Printf("ERROR: Unable to refresh %s: %s", key, err.Error())
+		return
+	}
+	q := req.URL.Query()
+	q.Set(RefreshParam, "")
+	req.URL.RawQuery = q.Encode()
+	req.RequestURI = req.URL.RequestURI()
+
+	resp := &bufferedResponse{header: make(http.Header), status: http.StatusOK}
+	handler.ServeHTTP(resp, req)
+	if resp.header.Get("Warning") != "" {
+		log.Printf("WARN: Unable to refresh %s, its last good version is kept", key)
+	} else if resp.status != http.StatusOK {
+		log.Printf("WARN: Unable to refresh %s, status %d", key, resp.status)
+	}
+}
+
+// stats returns the stats of the feed at key, the caller holds f.mu.
+func (f *Refresher) stats(key string) *feedStats {
+	stats, ok := f.feeds[key]
+	if !ok {
+		stats = &feedStats{}
+		f.feeds[key] = stats
+	}
+	return stats
+}
+
+// feedKey identifies the feed of r, a normalized url.
+func feedKey(r *http.Request) string {
+	q := r.URL.Query()
+	q.Del(RefreshParam)
+	return r.URL.Path + "?" + q.Encode()
+}
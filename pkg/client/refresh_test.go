@@ -0,0 +1,165 @@
+package client
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/sorae42/ressdit/pkg/cache"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	httpcache "github.com/victorspringer/http-cache"
+)
+
+// flakyFeeds renders a new version of every feed on each call, or fails while down.
+type flakyFeeds struct {
+	down    bool
+	renders int
+}
+
+func (f *flakyFeeds) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if f.down {
+		http.Error(w, "Reddit is down", http.StatusBadGateway)
+		return
+	}
+	f.renders++
+	fmt.Fprintf(w, "%s v%d", r.URL.Path, f.renders)
+}
+
+func newTestRefresher(t *testing.T, now NowFn) (*Refresher, *flakyFeeds, http.Handler) {
+	c := cache.NewMemory(1<<20, time.Now)
+	feeds := &flakyFeeds{}
+	refresher := NewRefresher(c, 10*time.Minute, NewRateLimiter(http.DefaultTransport, time.Second, now), 2, now)
+	cacheClient, err := httpcache.NewClient(
+		httpcache.ClientWithAdapter(cache.Adapter(c)),
+		httpcache.ClientWithTTL(time.Hour),
+		httpcache.ClientWithRefreshKey(RefreshParam),
+	)
+	require.NoError(t, err)
+	handler := NormalizeFeedURL(refresher.Track(refresher.ServeStale(cacheClient.Middleware(refresher.Render(feeds)))))
+	return refresher, feeds, handler
+}
+
+func get(h http.Handler, path string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+	return w
+}
+
+func TestServeStale(t *testing.T) {
+	_, feeds, h := newTestRefresher(t, time.Now)
+
+	w := get(h, "/r/golang")
+	assert.Equal(t, "/r/golang v1", w.Body.String())
+
+	// Reddit fails while the feed is rendered again, its last good version is served
+	feeds.down = true
+	w = get(h, "/r/golang?opn")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "/r/golang v1", w.Body.String())
+	assert.Equal(t, staleWarning, w.Header().Get("Warning"))
+
+	// feeds never rendered have nothing to fall back to
+	w = get(h, "/r/rust")
+	assert.Equal(t, http.StatusBadGateway, w.Code)
+
+	// the stale version was not cached, the feed is rendered as soon as Reddit is back
+	feeds.down = false
+	w = get(h, "/r/golang")
+	assert.Equal(t, "/r/golang v2", w.Body.String())
+	assert.Empty(t, w.Header().Get("Warning"))
+	w = get(h, "/r/golang")
+	assert.Equal(t, "/r/golang v2", w.Body.String())
+}
+
+func TestRefresh(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	refresher, feeds, h := newTestRefresher(t, func() time.Time { return now })
+
+	get(h, "/r/golang")
+	get(h, "/r/golang")
+	get(h, "/r/golang")
+	assert.Equal(t, 1, feeds.renders)
+
+	now = now.Add(9 * time.Minute)
+	keys := refresher.due(time.Minute)
+	assert.Equal(t, []string{"/r/golang?format=rss"}, keys)
+	refresher.refresh(h, keys)
+
+	// readers get the refreshed feed from the cache
+	w := get(h, "/r/golang")
+	assert.Equal(t, "/r/golang v2", w.Body.String())
+	assert.Equal(t, 2, feeds.renders)
+}
+
+func TestDue(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	clock := func() time.Time { return now }
+	refresher, _, _ := newTestRefresher(t, clock)
+	interval := time.Minute
+
+	refresher.feeds = map[string]*feedStats{
+		// rendered 9 minutes ago, it expires within two rounds
+		"/popular":    {hits: 10, rendered: now.Add(-9 * time.Minute)},
+		"/read":       {hits: 4, rendered: now.Add(-9 * time.Minute)},
+		"/rare":       {hits: 1, rendered: now.Add(-9 * time.Minute)},
+		"/fresh":      {hits: 10, rendered: now.Add(-5 * time.Minute)},
+		"/never":      {hits: 10},
+		"/refreshing": {hits: 10, rendered: now.Add(-9 * time.Minute), refreshing: true},
+		"/forgotten":  {hits: 1, rendered: now.Add(-time.Hour)},
+	}
+
+	assert.Equal(t, []string{"/popular", "/read"}, refresher.due(interval))
+	assert.True(t, refresher.feeds["/popular"].refreshing)
+	assert.False(t, refresher.feeds["/fresh"].refreshing)
+
+	// reads count half every round, feeds nobody reads are forgotten
+	assert.Equal(t, 5, refresher.feeds["/popular"].hits)
+	assert.Equal(t, 0, refresher.feeds["/rare"].hits)
+	assert.NotContains(t, refresher.feeds, "/forgotten")
+	assert.Contains(t, refresher.feeds, "/never")
+}
+
+func TestDueBudget(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	clock := func() time.Time { return now }
+	refresher, _, _ := newTestRefresher(t, clock)
+
+	budget := func(remaining int) {
+		refresher.limiter.update(&http.Response{StatusCode: http.StatusOK, Header: http.Header{
+			"X-Ratelimit-Remaining": {strconv.Itoa(remaining)},
+			"X-Ratelimit-Reset":     {"300"},
+		}})
+	}
+	feeds := func() {
+		refresher.feeds = make(map[string]*feedStats)
+		for i := 0; i < 5; i++ {
+			refresher.feeds[fmt.Sprintf("/feed%d", i)] = &feedStats{hits: 10 + i, rendered: now.Add(-9 * time.Minute)}
+		}
+	}
+
+	// the most read feeds go first, readers keep refreshReserve requests
+	feeds()
+	budget(refreshReserve + 2)
+	assert.Equal(t, []string{"/feed4", "/feed3"}, refresher.due(time.Minute))
+
+	feeds()
+	budget(refreshReserve - 10)
+	assert.Empty(t, refresher.due(time.Minute))
+
+	feeds()
+	budget(1000)
+	assert.Len(t, refresher.due(time.Minute), 5)
+
+	// once the window is over, the budget is unknown until Reddit tells
+	feeds()
+	budget(0)
+	now = now.Add(301 * time.Second)
+	for _, stats := range refresher.feeds {
+		stats.rendered = now.Add(-9 * time.Minute)
+	}
+	assert.Len(t, refresher.due(time.Minute), 5)
+}
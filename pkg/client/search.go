@@ -0,0 +1,168 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/cameronstanley/go-reddit"
+	"github.com/gorilla/feeds"
+)
+
+// searchPath matches a search of all of Reddit or of some subreddits.
+var searchPath = regexp.MustCompile(`(?i)^(\/r\/([a-z0-9_]+(\+[a-z0-9_]+)*))?\/search$`)
+
+var searchTimeDescriptions = map[string]string{
+	reddit.TimeHour:  "the past hour",
+	reddit.TimeDay:   "the past day",
+	reddit.TimeWeek:  "the past week",
+	reddit.TimeMonth: "the past month",
+	reddit.TimeYear:  "the past year",
+	reddit.TimeAll:   "all time",
+}
+
+var restrictValues = map[string]bool{
+	"":      false,
+	"0":     false,
+	"false": false,
+	"off":   false,
+	"1":     true,
+	"true":  true,
+	"on":    true,
+}
+
+func searchFeed(redditURL string, client *RedditClient, getArticle GetArticleFn, opts feedOptions, r *http.Request) (*feeds.Feed, error) {
+	query := r.URL.Query()
+	subreddit := searchPath.FindStringSubmatch(r.URL.Path)[2]
+
+	q := strings.TrimSpace(query.Get("q"))
+	if q == "" {
+		return nil, &feedError{http.StatusBadRequest, "Search query is missing, set it with ?q="}
+	}
+
+	search := reddit.SearchOptions{
+		Sort: strings.ToLower(query.Get("sort")),
+		Time: strings.ToLower(query.Get("t")),
+	}
+	if err := search.Validate(); err != nil {
+		return nil, &feedError{http.StatusBadRequest, err.Error()}
+	}
+
+	restrict, ok := restrictValues[strings.ToLower(query.Get("restrict_sr"))]
+	if !ok {
+		return nil, &feedError{http.StatusBadRequest, fmt.Sprintf("invalid restrict_sr: %s", query.Get("restrict_sr"))}
+	}
+	if subreddit == "" {
+		restrict = false
+	}
+
+	if restrict {
+		search.Subreddit = subreddit
+	}
+
+	links, err := searchLinks(redditURL, client, opts.filter, r, q, search)
+	if err != nil {
+		return nil, err
+	}
+
+	scope := "Reddit"
+	if restrict {
+		scope = "r/" + subreddit
+	}
+
+	description := fmt.Sprintf("Posts on %s matching \"%s\"", scope, q)
+	if search.Sort != "" {
+		description += fmt.Sprintf(", sorted by %s", search.Sort)
+	}
+	if search.Time != "" {
+		description += fmt.Sprintf(", from %s", searchTimeDescriptions[search.Time])
+	}
+
+	feed := &feeds.Feed{
+		Title:       fmt.Sprintf("%s search: %s", scope, q),
+		Link:        &feeds.Link{Href: fmt.Sprintf("https://www.reddit.com%s?%s", r.URL.Path, url.Values{"q": {q}}.Encode())},
+		Description: description,
+	}
+
+	addLinks(r, feed, client, getArticle, opts, links, !restrict || strings.Contains(subreddit, "+"))
+
+	return feed, nil
+}
+
+// searchLinks runs search for q on Reddit, following the result pages like fetchLinks follows a listing.
+func searchLinks(redditURL string, client *RedditClient, filter linkFilter, r *http.Request, q string, search reddit.SearchOptions) ([]linkListingChildren, error) {
+	p := newPagination(r)
+
+	var result []linkListingChildren
+	seen := make(map[string]bool)
+	matched := 0
+
+	for page := 0; page < p.pages; page++ {
+		links, err := searchPage(redditURL, client, r, q, search)
+		if err != nil {
+			return nil, err
+		}
+
+		fresh := 0
+		for _, link := range links {
+			if seen[link.ID] {
+				continue
+			}
+			seen[link.ID] = true
+			fresh++
+
+			// filtered links are kept, they still describe the results
+			if filter.match(link) {
+				if p.items > 0 && matched >= p.items {
+					continue
+				}
+				matched++
+			}
+			result = append(result, linkListingChildren{Kind: "t3", Data: *link})
+		}
+
+		if fresh == 0 || (p.items > 0 && matched >= p.items) {
+			break
+		}
+		// the next page starts after the last link of this one
+		search.After = links[len(links)-1].Name
+		search.Count = len(seen)
+	}
+
+	return result, nil
+}
+
+// searchPage gets a page of search results from Reddit, or from the listing cache.
+func searchPage(redditURL string, client *RedditClient, r *http.Request, q string, search reddit.SearchOptions) ([]*reddit.Link, error) {
+	key := fmt.Sprintf("search:%s?%s", redditURL, url.Values{
+		"q":         {q},
+		"subreddit": {search.Subreddit},
+		"sort":      {search.Sort},
+		"t":         {search.Time},
+		"after":     {search.After},
+	}.Encode())
+	if client.Cache != nil && !refreshing(r) {
+		if body, ok := client.Cache.Get(key); ok {
+			var links []*reddit.Link
+			if err := json.Unmarshal(body, &links); err == nil {
+				return links, nil
+			}
+		}
+	}
+
+	links, err := client.apiClient(redditURL).SearchLinks(r.Context(), q, search)
+	if err != nil {
+		return nil, redditError("Search", err)
+	}
+
+	if client.Cache != nil {
+		if body, err := json.Marshal(links); err == nil {
+			client.Cache.Set(key, body, client.ListingTTL)
+		}
+	}
+
+	return links, nil
+}
@@ -0,0 +1,75 @@
+package client
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/sorae42/ressdit/pkg/cache"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// searchResults serves two pages of search results, recording the path and query of every search.
+func searchResults(t *testing.T) (*upstream, *[]string) {
+	var searches []string
+	pages := map[string]string{
+		"":     `{"kind": "t3", "data": {"name": "t3_a", "id": "a", "title": "Post a", "subreddit": "golang", "permalink": "/r/golang/comments/a/"}}`,
+		"t3_a": `{"kind": "t3", "data": {"name": "t3_b", "id": "b", "title": "Post b", "subreddit": "golang", "permalink": "/r/golang/comments/b/"}}`,
+	}
+
+	u := &upstream{}
+	u.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		searches = append(searches, r.URL.Path+"?"+r.URL.RawQuery)
+		fmt.Fprintf(w, `{"kind": "Listing", "data": {"children": [%s]}}`, pages[r.URL.Query().Get("after")])
+	}))
+	t.Cleanup(u.Close)
+	return u, &searches
+}
+
+func TestSearchFeed(t *testing.T) {
+	cases := []struct {
+		path string
+		want []string
+	}{
+		{"/search?q=ressdit", []string{"/search.json?q=ressdit&type=link"}},
+		{"/r/golang/search?q=ressdit", []string{"/search.json?q=ressdit&type=link"}},
+		{"/r/golang/search?q=ressdit&restrict_sr=1&sort=NEW&t=week", []string{"/r/golang/search.json?q=ressdit&restrict_sr=1&sort=new&t=week&type=link"}},
+		{"/search?q=ressdit&pages=3", []string{
+			"/search.json?q=ressdit&type=link",
+			"/search.json?after=t3_a&count=1&q=ressdit&type=link",
+			"/search.json?after=t3_b&count=2&q=ressdit&type=link",
+		}},
+	}
+	for _, c := range cases {
+		u, searches := searchResults(t)
+		w := serveFeedRequest(u, u.client(), nil, httptest.NewRequest("GET", c.path, nil))
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Equal(t, c.want, *searches, c.path)
+		assert.Contains(t, w.Body.String(), "Post a", c.path)
+	}
+}
+
+func TestSearchFeedInvalid(t *testing.T) {
+	for _, query := range []string{"", "?q=+", "?q=go&sort=newest", "?q=go&t=decade", "?q=go&restrict_sr=maybe"} {
+		u, searches := searchResults(t)
+		w := serveFeedRequest(u, u.client(), nil, httptest.NewRequest("GET", "/r/golang/search"+query, nil))
+		assert.Equal(t, http.StatusBadRequest, w.Code, query)
+		assert.Empty(t, *searches, query)
+	}
+}
+
+func TestSearchFeedCached(t *testing.T) {
+	u, searches := searchResults(t)
+	client := u.client()
+	client.Cache = cache.NewMemory(1<<20, time.Now)
+	client.ListingTTL = time.Minute
+
+	for _, query := range []string{"q=ressdit", "q=ressdit&scoreLimit=10", "q=ressdit&format=atom"} {
+		w := serveFeedRequest(u, client, nil, httptest.NewRequest("GET", "/search?"+query, nil))
+		assert.Equal(t, http.StatusOK, w.Code, query)
+	}
+	assert.Len(t, *searches, 1)
+}
@@ -0,0 +1,87 @@
+package client
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gorilla/feeds"
+	"github.com/sorae42/ressdit/pkg/store"
+)
+
+// defaultNewWindow is how recently an item must have shown up in a feed to be kept by "?onlyNew=true".
+const defaultNewWindow = 24 * time.Hour
+
+// seenParams are the query parameters changing which items a feed lists: the feed parameters
+// filtering them and the Reddit ones picking the listing. Others, like the output format or
+// unknown ones, share the feed of the request without them.
+var seenParams = []string{"safe", "scoreLimit", "flair", "filter", "pages", "items", "digest", "top", "q", "sort", "t", "restrict_sr"}
+
+// seenFeedKey identifies the feed of a request in the seen store: its path and the parameters of seenParams.
+func seenFeedKey(r *http.Request) string {
+	query := r.URL.Query()
+	key := url.Values{}
+	for _, param := range seenParams {
+		if values, ok := query[param]; ok {
+			key[param] = values
+		}
+	}
+	return strings.ToLower(r.URL.Path) + "?" + key.Encode()
+}
+
+// onlyNewWindow parses the "onlyNew" query parameter, either "true" or a duration like "6h".
+// A zero window means every item is kept.
+func onlyNewWindow(r *http.Request) (time.Duration, error) {
+	value := strings.ToLower(r.URL.Query().Get("onlyNew"))
+	switch value {
+	case "", "false", "0":
+		return 0, nil
+	case "true", "1":
+		return defaultNewWindow, nil
+	}
+
+	window, err := time.ParseDuration(value)
+	if err != nil || window <= 0 {
+		return 0, &feedError{http.StatusBadRequest, fmt.Sprintf("invalid onlyNew: %s, expected true, false or a duration like 6h", value)}
+	}
+	return window, nil
+}
+
+// applySeen dates the items of feed by the first time they showed up in it, so reordered listings
+// do not make old items look new. Updates that happened after that are kept. Items that showed up longer than window ago are dropped, unless window is zero.
+func applySeen(seen *store.Store, now NowFn, r *http.Request, window time.Duration, feed *feeds.Feed) error {
+	ids := make([]string, 0, len(feed.Items))
+	for _, item := range feed.Items {
+		ids = append(ids, item.Id)
+	}
+
+	t := now()
+	firstSeen, err := seen.Seen(seenFeedKey(r), ids, t)
+	if err != nil {
+		return err
+	}
+
+	items := feed.Items[:0]
+	for _, item := range feed.Items {
+		first := firstSeen[item.Id]
+		if window > 0 && first.Before(t.Add(-window)) {
+			continue
+		}
+
+		// Updated belongs to the feeds tracking changes of their items, like digests and conversations
+		item.Created = first
+		if item.Updated.Before(first) {
+			item.Updated = time.Time{}
+		}
+		items = append(items, item)
+	}
+	feed.Items = items
+
+	feed.Sort(func(a, b *feeds.Item) bool {
+		return a.Created.After(b.Created)
+	})
+
+	return nil
+}
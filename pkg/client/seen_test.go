@@ -0,0 +1,112 @@
+package client
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gorilla/feeds"
+	"github.com/sorae42/ressdit/pkg/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplySeen(t *testing.T) {
+	seen, err := store.Open(filepath.Join(t.TempDir(), "seen.db"), time.Hour)
+	require.NoError(t, err)
+	defer seen.Close()
+
+	first := time.Unix(1700000000, 0)
+	posted := first.Add(-48 * time.Hour)
+	r := httptest.NewRequest("GET", "/r/golang", nil)
+
+	feed := &feeds.Feed{Items: []*feeds.Item{
+		{Id: "link", Created: posted},
+		{Id: "digest", Created: posted, Updated: posted.Add(time.Hour)},
+	}}
+	require.NoError(t, applySeen(seen, func() time.Time { return first }, r, 6*time.Hour, feed))
+	for _, item := range feed.Items {
+		assert.Equal(t, first, item.Created, item.Id)
+		assert.True(t, item.Updated.IsZero(), item.Id)
+	}
+
+	// the digest changed since it was first seen, a new item showed up
+	later := first.Add(2 * time.Hour)
+	feed = &feeds.Feed{Items: []*feeds.Item{
+		{Id: "link", Created: posted},
+		{Id: "digest", Created: posted, Updated: later.Add(-time.Minute)},
+		{Id: "new", Created: later.Add(-time.Minute)},
+	}}
+	require.NoError(t, applySeen(seen, func() time.Time { return later }, r, 6*time.Hour, feed))
+	assert.Len(t, feed.Items, 3)
+	assert.Equal(t, "new", feed.Items[0].Id)
+	assert.Equal(t, later, feed.Items[0].Created)
+	for _, item := range feed.Items[1:] {
+		assert.Equal(t, first, item.Created, item.Id)
+		if item.Id == "digest" {
+			assert.Equal(t, later.Add(-time.Minute), item.Updated)
+		}
+	}
+
+	// onlyNew drops the items first seen more than 6 hours ago
+	feed = &feeds.Feed{Items: []*feeds.Item{{Id: "link"}, {Id: "new"}}}
+	require.NoError(t, applySeen(seen, func() time.Time { return first.Add(7 * time.Hour) }, r, 6*time.Hour, feed))
+	if assert.Len(t, feed.Items, 1) {
+		assert.Equal(t, "new", feed.Items[0].Id)
+	}
+}
+
+func TestSeenFeedKey(t *testing.T) {
+	key := func(target string) string {
+		return seenFeedKey(httptest.NewRequest("GET", target, nil))
+	}
+
+	// the format, onlyNew and unknown parameters share the feed
+	assert.Equal(t, "/r/golang?", key("/r/golang"))
+	assert.Equal(t, "/r/golang?", key("/r/Golang?format=atom&onlyNew=true&utm_source=x&junk=1"))
+
+	// the filters and the listing do not
+	assert.Equal(t, "/r/golang?flair=News&safe=true", key("/r/golang?safe=true&junk=1&flair=News"))
+	assert.Equal(t, "/r/golang/top?t=week", key("/r/golang/top?t=week&format=json"))
+	assert.Equal(t, "/search?q=go&restrict_sr=1&sort=new", key("/search?sort=new&q=go&restrict_sr=1&x=y"))
+}
+
+func TestOnlyNewWindow(t *testing.T) {
+	tests := []struct {
+		value  string
+		window time.Duration
+	}{
+		{"", 0},
+		{"false", 0},
+		{"0", 0},
+		{"true", defaultNewWindow},
+		{"1", defaultNewWindow},
+		{"6h", 6 * time.Hour},
+	}
+	for _, test := range tests {
+		window, err := onlyNewWindow(httptest.NewRequest("GET", "/r/golang?onlyNew="+test.value, nil))
+		require.NoError(t, err, test.value)
+		assert.Equal(t, test.window, window, test.value)
+	}
+
+	for _, value := range []string{"yes", "-6h", "0s", "6"} {
+		_, err := onlyNewWindow(httptest.NewRequest("GET", "/r/golang?onlyNew="+value, nil))
+		var feedErr *feedError
+		if assert.ErrorAs(t, err, &feedErr, value) {
+			assert.Equal(t, http.StatusBadRequest, feedErr.status, value)
+		}
+	}
+}
+
+func TestOnlyNewInvalid(t *testing.T) {
+	u := newUpstream(t, map[string]string{"/r/golang.json": "links.json"})
+
+	// the feed is rejected before asking Reddit
+	w := serveFeedRequest(u, u.client(), nil, httptest.NewRequest("GET", "/r/golang?onlyNew=soon", nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "invalid onlyNew")
+	assert.Zero(t, atomic.LoadInt32(&u.requests))
+}
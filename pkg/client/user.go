@@ -0,0 +1,148 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"log"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/cameronstanley/go-reddit"
+	"github.com/gorilla/feeds"
+	"github.com/graph-gophers/dataloader"
+)
+
+// userPath matches the overview, submitted and comments listings of a user.
+var userPath = regexp.MustCompile(`(?i)^\/(user|u)\/([a-z0-9_-]+)(\/(overview|submitted|comments))?$`)
+
+// thingListing is a listing that mixes links and comments, like a user's overview.
+type thingListing struct {
+	Kind string `json:"kind"`
+	Data struct {
+		Children []struct {
+			Kind string          `json:"kind"`
+			Data json.RawMessage `json:"data"`
+		} `json:"children"`
+		After string `json:"after"`
+	} `json:"data"`
+}
+
+var userListingTitles = map[string]string{
+	"":          "Overview",
+	"overview":  "Overview",
+	"submitted": "Posts",
+	"comments":  "Comments",
+}
+
+func userFeed(redditURL string, client *RedditClient, getArticle GetArticleFn, opts feedOptions, r *http.Request) (*feeds.Feed, error) {
+	match := userPath.FindStringSubmatch(r.URL.Path)
+	username, where := match[2], strings.ToLower(match[4])
+
+	result, err := fetchThings(redditURL, client, opts.filter, r, userPath, "User")
+	if err != nil {
+		return nil, err
+	}
+
+	feed := &feeds.Feed{
+		Title:       fmt.Sprintf("u/%s: %s", username, userListingTitles[where]),
+		Link:        &feeds.Link{Href: fmt.Sprintf("https://www.reddit.com/user/%s", username)},
+		Description: fmt.Sprintf("Activity of u/%s on Reddit", username),
+	}
+
+	// the listing carries no details about the user, ask for them separately
+	account, err := client.apiClient(redditURL).GetUserInfo(r.Context(), username)
+	if err != nil {
+		log.Printf("WARN: Unable to get info of user %s: %s", username, err.Error())
+	} else if account.Name != "" {
+		feed.Title = fmt.Sprintf("u/%s: %s", account.Name, userListingTitles[where])
+		feed.Link.Href = fmt.Sprintf("https://www.reddit.com/user/%s", account.Name)
+		if account.Subreddit != nil && account.Subreddit.PublicDescription != "" {
+			feed.Description = account.Subreddit.PublicDescription
+		}
+		if account.IconImg != "" {
+			feed.Image = &feeds.Image{
+				Url:   fixAmp(account.IconImg),
+				Title: feed.Title,
+				Link:  feed.Link.Href,
+			}
+		}
+	}
+
+	err = addThings(r, feed, client, getArticle, opts, *result)
+	if err != nil {
+		return nil, err
+	}
+
+	return feed, nil
+}
+
+// addThings renders the links and comments of listing that pass the requested filters as items of feed, in listing order.
+func addThings(r *http.Request, feed *feeds.Feed, client *RedditClient, getArticle GetArticleFn, opts feedOptions, listing thingListing) error {
+	ctx := r.Context()
+	loader := articleLoader(client, getArticle, opts.article)
+
+	// keep the listing order while posts are rendered concurrently
+	var thunks []dataloader.Thunk
+	for _, child := range listing.Data.Children {
+		if !opts.filter.matchThing(child.Kind, child.Data) {
+			continue
+		}
+
+		switch child.Kind {
+		case "t3":
+			var link reddit.Link
+			if err := json.Unmarshal(child.Data, &link); err != nil {
+				return fmt.Errorf("JSON: %w", err)
+			}
+			thunks = append(thunks, loader.Load(ctx, dataKey(link)))
+		case "t1":
+			var comment reddit.Comment
+			if err := json.Unmarshal(child.Data, &comment); err != nil {
+				return fmt.Errorf("JSON: %w", err)
+			}
+			item := userCommentToFeed(&comment)
+			thunks = append(thunks, func() (interface{}, error) { return item, nil })
+		}
+	}
+
+	for _, thunk := range thunks {
+		val, err := thunk()
+		if err != nil {
+			continue
+		}
+
+		feed.Items = append(feed.Items, val.(*feeds.Item))
+	}
+
+	return nil
+}
+
+// userCommentToFeed renders a comment from a user listing, which links back to the post it was made on.
+func userCommentToFeed(comment *reddit.Comment) *feeds.Item {
+	item := commentToFeed(comment, comment.LinkTitle)
+	item.Content += fmt.Sprintf(`<p><small>in <a href="%s">%s</a> on r/%s</small></p>`,
+		fmt.Sprintf("%s%s", frontendURL(), strings.TrimPrefix(comment.LinkPermalink, "https://www.reddit.com")),
+		html.EscapeString(comment.LinkTitle), comment.Subreddit)
+
+	return item
+}
+
+// matchThing reports whether the thing of kind, a link or a comment, passes the filters.
+// Comments are matched along with what the listing tells of their post.
+func (f linkFilter) matchThing(kind string, data json.RawMessage) bool {
+	switch kind {
+	case "t3":
+		var link reddit.Link
+		return json.Unmarshal(data, &link) == nil && f.match(&link)
+	case "t1":
+		var comment reddit.Comment
+		if json.Unmarshal(data, &comment) != nil {
+			return false
+		}
+		post := &reddit.Link{Title: comment.LinkTitle, URL: comment.LinkURL, Subreddit: comment.Subreddit}
+		return f.match(commentLink(&comment, post))
+	}
+	return false
+}
@@ -0,0 +1,61 @@
+package client
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// userListing serves two pages of a user's overview, a post and a comment each, following the "after" cursor.
+func userListing(t *testing.T) (*upstream, *[]string) {
+	var afters []string
+	pages := map[string]string{
+		"":     `{"kind": "t3", "data": {"name": "t3_a", "id": "a", "title": "Post a", "score": 10, "permalink": "/r/golang/comments/a/"}}, {"kind": "t1", "data": {"name": "t1_b", "id": "b", "body": "comment b", "score": 1}}`,
+		"t1_b": `{"kind": "t3", "data": {"name": "t3_c", "id": "c", "title": "Post c", "score": 20, "permalink": "/r/golang/comments/c/"}}, {"kind": "t1", "data": {"name": "t1_d", "id": "d", "body": "comment d", "score": 30}}`,
+	}
+	next := map[string]string{"": "t1_b", "t1_b": "t1_d"}
+
+	u := &upstream{}
+	u.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/user/alice.json" {
+			http.NotFound(w, r)
+			return
+		}
+		after := r.URL.Query().Get("after")
+		afters = append(afters, after)
+		fmt.Fprintf(w, `{"kind": "Listing", "data": {"after": %q, "children": [%s]}}`, next[after], pages[after])
+	}))
+	t.Cleanup(u.Close)
+	return u, &afters
+}
+
+func TestUserFeedPages(t *testing.T) {
+	cases := []struct {
+		query  string
+		afters []string
+		items  int
+	}{
+		{"", []string{""}, 2},
+		{"?pages=2", []string{"", "t1_b"}, 4},
+		{"?items=3", []string{"", "t1_b"}, 3},
+		{"?items=3&scoreLimit=15", []string{"", "t1_b", "t1_d"}, 2},
+	}
+	for _, c := range cases {
+		t.Run(c.query, func(t *testing.T) {
+			u, afters := userListing(t)
+			req := httptest.NewRequest("GET", "/user/alice"+c.query, nil)
+			w := httptest.NewRecorder()
+			RssHandler(u.URL, time.Now, u.client(), testArticle, nil, w, req)
+			require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+			assert.Equal(t, c.afters, *afters)
+			assert.Equal(t, c.items, strings.Count(w.Body.String(), "<item>"))
+		})
+	}
+}
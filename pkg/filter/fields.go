@@ -0,0 +1,70 @@
+package filter
+
+import (
+	"github.com/cameronstanley/go-reddit"
+)
+
+type fieldKind int
+
+const (
+	numberField fieldKind = iota
+	stringField
+	boolField
+)
+
+func (k fieldKind) String() string {
+	switch k {
+	case numberField:
+		return "number"
+	case stringField:
+		return "string"
+	default:
+		return "boolean"
+	}
+}
+
+// field reads one property of a link. Only the getter matching its kind is set.
+type field struct {
+	kind      fieldKind
+	getNumber func(link *reddit.Link) float64
+	getString func(link *reddit.Link) string
+	getBool   func(link *reddit.Link) bool
+}
+
+func number(get func(link *reddit.Link) float64) field {
+	return field{kind: numberField, getNumber: get}
+}
+
+func str(get func(link *reddit.Link) string) field {
+	return field{kind: stringField, getString: get}
+}
+
+func boolean(get func(link *reddit.Link) bool) field {
+	return field{kind: boolField, getBool: get}
+}
+
+// fields are the link properties an expression can refer to.
+var fields = map[string]field{
+	"score":    number(func(l *reddit.Link) float64 { return float64(l.Score) }),
+	"ups":      number(func(l *reddit.Link) float64 { return float64(l.Ups) }),
+	"comments": number(func(l *reddit.Link) float64 { return float64(l.NumComments) }),
+	"gilded":   number(func(l *reddit.Link) float64 { return float64(l.Gilded) }),
+	"created":  number(func(l *reddit.Link) float64 { return l.CreatedUtc }),
+
+	"id":          str(func(l *reddit.Link) string { return l.ID }),
+	"title":       str(func(l *reddit.Link) string { return l.Title }),
+	"author":      str(func(l *reddit.Link) string { return l.Author }),
+	"domain":      str(func(l *reddit.Link) string { return l.Domain }),
+	"flair":       str(func(l *reddit.Link) string { return l.LinkFlairText }),
+	"authorFlair": str(func(l *reddit.Link) string { return l.AuthorFlairText }),
+	"subreddit":   str(func(l *reddit.Link) string { return l.Subreddit }),
+	"url":         str(func(l *reddit.Link) string { return l.URL }),
+	"selftext":    str(func(l *reddit.Link) string { return l.Selftext }),
+
+	"over18":     boolean(func(l *reddit.Link) bool { return l.Over18 }),
+	"self":       boolean(func(l *reddit.Link) bool { return l.IsSelf }),
+	"stickied":   boolean(func(l *reddit.Link) bool { return l.Stickied }),
+	"locked":     boolean(func(l *reddit.Link) bool { return l.Locked }),
+	"archived":   boolean(func(l *reddit.Link) bool { return l.Archived }),
+	"quarantine": boolean(func(l *reddit.Link) bool { return l.Quarantine }),
+}
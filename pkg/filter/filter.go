@@ -0,0 +1,408 @@
+// Package filter parses and evaluates filter expressions on Reddit links, such as
+//
+//	score>50 && !over18 && (flair~"Guide" || domain=="github.com") && title!~"(?i)meme"
+//
+// Comparisons take a link field on the left and a literal on the right. Numbers support
+// ==, !=, <, <=, > and >=; strings support ==, != and the regular expression matches ~ and !~;
+// booleans support == and != or can be used on their own. Comparisons are combined with
+// !, && and || and grouped with parentheses.
+package filter
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/cameronstanley/go-reddit"
+)
+
+// Filter is a parsed filter expression.
+type Filter struct {
+	source string
+	root   node
+}
+
+// SyntaxError describes why and where an expression could not be parsed.
+type SyntaxError struct {
+	// Pos is the byte offset of the failing token in the expression.
+	Pos int
+	Msg string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("position %d: %s", e.Pos, e.Msg)
+}
+
+// Parse compiles an expression. Errors are of type *SyntaxError.
+func Parse(source string) (*Filter, error) {
+	tokens, err := lex(source)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{tokens: tokens}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokenEOF {
+		return nil, &SyntaxError{tok.pos, fmt.Sprintf("unexpected %s", tok)}
+	}
+
+	return &Filter{source: source, root: root}, nil
+}
+
+// Match reports whether link satisfies the expression.
+func (f *Filter) Match(link *reddit.Link) bool {
+	return f.root.eval(link)
+}
+
+func (f *Filter) String() string {
+	return f.source
+}
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenIdent
+	tokenNumber
+	tokenString
+	tokenOp
+	tokenLParen
+	tokenRParen
+	tokenAnd
+	tokenOr
+	tokenNot
+)
+
+type token struct {
+	kind  tokenKind
+	pos   int
+	text  string
+	value string
+}
+
+func (t token) String() string {
+	if t.kind == tokenEOF {
+		return "end of expression"
+	}
+	return fmt.Sprintf("%q", t.text)
+}
+
+// operators are listed longest first so "!=" is not read as "!".
+var operators = []struct {
+	text string
+	kind tokenKind
+}{
+	{"&&", tokenAnd},
+	{"||", tokenOr},
+	{"==", tokenOp},
+	{"!=", tokenOp},
+	{"!~", tokenOp},
+	{">=", tokenOp},
+	{"<=", tokenOp},
+	{">", tokenOp},
+	{"<", tokenOp},
+	{"~", tokenOp},
+	{"!", tokenNot},
+	{"(", tokenLParen},
+	{")", tokenRParen},
+}
+
+func isIdentByte(c byte, first bool) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (!first && c >= '0' && c <= '9')
+}
+
+func isNumberByte(c byte) bool {
+	return c == '.' || (c >= '0' && c <= '9')
+}
+
+func lex(source string) ([]token, error) {
+	var tokens []token
+	i := 0
+
+next:
+	for i < len(source) {
+		c := source[i]
+
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+			continue
+		case isIdentByte(c, true):
+			start := i
+			for i < len(source) && isIdentByte(source[i], false) {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenIdent, pos: start, text: source[start:i]})
+			continue
+		case isNumberByte(c) || (c == '-' && i+1 < len(source) && isNumberByte(source[i+1])):
+			start := i
+			i++
+			for i < len(source) && isNumberByte(source[i]) {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenNumber, pos: start, text: source[start:i]})
+			continue
+		case c == '"' || c == '\'':
+			start := i
+			var b strings.Builder
+			for i++; i < len(source); i++ {
+				switch source[i] {
+				case '\\':
+					if i+1 < len(source) {
+						i++
+						b.WriteByte(source[i])
+					}
+				case c:
+					i++
+					tokens = append(tokens, token{kind: tokenString, pos: start, text: source[start:i], value: b.String()})
+					continue next
+				default:
+					b.WriteByte(source[i])
+				}
+			}
+			return nil, &SyntaxError{start, "unterminated string"}
+		}
+
+		for _, op := range operators {
+			if strings.HasPrefix(source[i:], op.text) {
+				tokens = append(tokens, token{kind: op.kind, pos: i, text: op.text})
+				i += len(op.text)
+				continue next
+			}
+		}
+
+		return nil, &SyntaxError{i, fmt.Sprintf("unexpected character %q", c)}
+	}
+
+	tokens = append(tokens, token{kind: tokenEOF, pos: len(source)})
+	return tokens, nil
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokenEOF {
+		p.pos++
+	}
+	return tok
+}
+
+// parseOr parses: and ("||" and)*
+func (p *parser) parseOr() (node, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.peek().kind == tokenOr {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = orNode{left, right}
+	}
+
+	return left, nil
+}
+
+// parseAnd parses: unary ("&&" unary)*
+func (p *parser) parseAnd() (node, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.peek().kind == tokenAnd {
+		p.next()
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = andNode{left, right}
+	}
+
+	return left, nil
+}
+
+// parseUnary parses: "!" unary | "(" or ")" | comparison
+func (p *parser) parseUnary() (node, error) {
+	tok := p.next()
+
+	switch tok.kind {
+	case tokenNot:
+		n, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return notNode{n}, nil
+	case tokenLParen:
+		n, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if closing := p.next(); closing.kind != tokenRParen {
+			return nil, &SyntaxError{closing.pos, fmt.Sprintf("expected \")\", found %s", closing)}
+		}
+		return n, nil
+	case tokenIdent:
+		return p.parseComparison(tok)
+	default:
+		return nil, &SyntaxError{tok.pos, fmt.Sprintf("expected field, \"!\" or \"(\", found %s", tok)}
+	}
+}
+
+// parseComparison parses the rest of: field (op literal)?
+func (p *parser) parseComparison(name token) (node, error) {
+	f, ok := fields[name.text]
+	if !ok {
+		return nil, &SyntaxError{name.pos, fmt.Sprintf("unknown field %q", name.text)}
+	}
+
+	if p.peek().kind != tokenOp {
+		if f.kind != boolField {
+			return nil, &SyntaxError{name.pos, fmt.Sprintf("%s field %q needs a comparison", f.kind, name.text)}
+		}
+		return boolNode{f.getBool, true, false}, nil
+	}
+
+	op := p.next()
+	literal := p.next()
+
+	switch f.kind {
+	case numberField:
+		if literal.kind != tokenNumber {
+			return nil, &SyntaxError{literal.pos, fmt.Sprintf("expected number, found %s", literal)}
+		}
+		value, err := strconv.ParseFloat(literal.text, 64)
+		if err != nil {
+			return nil, &SyntaxError{literal.pos, fmt.Sprintf("invalid number %s", literal)}
+		}
+		if op.text == "~" || op.text == "!~" {
+			return nil, &SyntaxError{op.pos, fmt.Sprintf("operator %s does not apply to numbers", op)}
+		}
+		return numberNode{f.getNumber, op.text, value}, nil
+
+	case stringField:
+		if literal.kind != tokenString {
+			return nil, &SyntaxError{literal.pos, fmt.Sprintf("expected quoted string, found %s", literal)}
+		}
+		n := stringNode{get: f.getString, op: op.text, value: literal.value}
+		switch op.text {
+		case "~", "!~":
+			re, err := regexp.Compile(literal.value)
+			if err != nil {
+				return nil, &SyntaxError{literal.pos, fmt.Sprintf("invalid regular expression: %s", err.Error())}
+			}
+			n.re = re
+		case "==", "!=":
+		default:
+			return nil, &SyntaxError{op.pos, fmt.Sprintf("operator %s does not apply to strings", op)}
+		}
+		return n, nil
+
+	default:
+		if literal.kind != tokenIdent || (literal.text != "true" && literal.text != "false") {
+			return nil, &SyntaxError{literal.pos, fmt.Sprintf("expected true or false, found %s", literal)}
+		}
+		if op.text != "==" && op.text != "!=" {
+			return nil, &SyntaxError{op.pos, fmt.Sprintf("operator %s does not apply to booleans", op)}
+		}
+		return boolNode{f.getBool, literal.text == "true", op.text == "!="}, nil
+	}
+}
+
+type node interface {
+	eval(link *reddit.Link) bool
+}
+
+type orNode struct {
+	left, right node
+}
+
+func (n orNode) eval(link *reddit.Link) bool {
+	return n.left.eval(link) || n.right.eval(link)
+}
+
+type andNode struct {
+	left, right node
+}
+
+func (n andNode) eval(link *reddit.Link) bool {
+	return n.left.eval(link) && n.right.eval(link)
+}
+
+type notNode struct {
+	n node
+}
+
+func (n notNode) eval(link *reddit.Link) bool {
+	return !n.n.eval(link)
+}
+
+type boolNode struct {
+	get    func(link *reddit.Link) bool
+	value  bool
+	negate bool
+}
+
+func (n boolNode) eval(link *reddit.Link) bool {
+	return (n.get(link) == n.value) != n.negate
+}
+
+type numberNode struct {
+	get   func(link *reddit.Link) float64
+	op    string
+	value float64
+}
+
+func (n numberNode) eval(link *reddit.Link) bool {
+	v := n.get(link)
+	switch n.op {
+	case "==":
+		return v == n.value
+	case "!=":
+		return v != n.value
+	case ">":
+		return v > n.value
+	case ">=":
+		return v >= n.value
+	case "<":
+		return v < n.value
+	default:
+		return v <= n.value
+	}
+}
+
+type stringNode struct {
+	get   func(link *reddit.Link) string
+	op    string
+	value string
+	re    *regexp.Regexp
+}
+
+func (n stringNode) eval(link *reddit.Link) bool {
+	v := n.get(link)
+	switch n.op {
+	case "==":
+		return v == n.value
+	case "!=":
+		return v != n.value
+	case "~":
+		return n.re.MatchString(v)
+	default:
+		return !n.re.MatchString(v)
+	}
+}
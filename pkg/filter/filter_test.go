@@ -0,0 +1,112 @@
+package filter
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/cameronstanley/go-reddit"
+	"github.com/stretchr/testify/assert"
+)
+
+var testLink = &reddit.Link{
+	ID:            "abc123",
+	Title:         "Go 1.22 is released",
+	Author:        "gopher",
+	Domain:        "go.dev",
+	LinkFlairText: "News",
+	Subreddit:     "golang",
+	URL:           "https://go.dev/blog/go1.22",
+	Score:         120,
+	Ups:           130,
+	NumComments:   42,
+	CreatedUtc:    1707350400,
+	Over18:        false,
+	IsSelf:        false,
+	Stickied:      true,
+}
+
+func TestMatch(t *testing.T) {
+	cases := []struct {
+		expr string
+		want bool
+	}{
+		// numbers
+		{"score == 120", true},
+		{"score != 120", false},
+		{"score > 100", true},
+		{"score > 120", false},
+		{"score >= 120", true},
+		{"score < 120", false},
+		{"score <= 120", true},
+		{"comments<50", true},
+		{"created > 1700000000", true},
+		{"score > -1.5", true},
+		// strings
+		{`author == "gopher"`, true},
+		{`author != "gopher"`, false},
+		{`title ~ "^Go 1\\.\\d+"`, true},
+		{`title !~ "(?i)meme"`, true},
+		{`flair ~ "Guide"`, false},
+		{`domain == 'go.dev'`, true},
+		{`title == "say \"hi\""`, false},
+		// booleans
+		{"stickied", true},
+		{"over18", false},
+		{"!over18", true},
+		{"over18 == false", true},
+		{"stickied != true", false},
+		// precedence: && binds tighter than ||, ! tighter than &&
+		{"score > 1000 && over18 || stickied", true},
+		{"score > 1000 && (over18 || stickied)", false},
+		{"stickied || score > 1000 && over18", true},
+		{"(stickied || score > 1000) && over18", false},
+		{"!stickied && over18", false},
+		{"!(stickied && over18)", true},
+		{"!!stickied", true},
+		{`score>50 && !over18 && (flair~"Guide" || domain=="go.dev") && title!~"(?i)meme"`, true},
+	}
+
+	for _, c := range cases {
+		f, err := Parse(c.expr)
+		if assert.NoError(t, err, c.expr) {
+			assert.Equal(t, c.want, f.Match(testLink), c.expr)
+			assert.Equal(t, c.expr, f.String())
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	cases := []struct {
+		expr string
+		pos  int
+		msg  string
+	}{
+		{"karma > 1", 0, `unknown field "karma"`},
+		{"score > 1 && karma", 13, `unknown field "karma"`},
+		{"score", 0, `number field "score" needs a comparison`},
+		{"title", 0, `string field "title" needs a comparison`},
+		{`score > "1"`, 8, `expected number, found "\"1\""`},
+		{"score ~ 1", 6, "operator \"~\" does not apply to numbers"},
+		{"title == gopher", 9, `expected quoted string, found "gopher"`},
+		{`title > "a"`, 6, "operator \">\" does not apply to strings"},
+		{`title ~ "("`, 8, "invalid regular expression"},
+		{"over18 == yes", 10, `expected true or false, found "yes"`},
+		{"over18 < true", 7, "operator \"<\" does not apply to booleans"},
+		{`title == "open`, 9, "unterminated string"},
+		{"score > 1 # 2", 10, "unexpected character '#'"},
+		{"(score > 1", 10, `expected ")", found end of expression`},
+		{"score > 1)", 9, `unexpected ")"`},
+		{"&& score > 1", 0, `expected field, "!" or "(", found "&&"`},
+		{"", 0, `expected field, "!" or "(", found end of expression`},
+		{"score > 1 &&", 12, `expected field, "!" or "(", found end of expression`},
+	}
+
+	for _, c := range cases {
+		_, err := Parse(c.expr)
+		var syntaxErr *SyntaxError
+		if assert.True(t, errors.As(err, &syntaxErr), c.expr) {
+			assert.Equal(t, c.pos, syntaxErr.Pos, c.expr)
+			assert.Contains(t, syntaxErr.Msg, c.msg, c.expr)
+		}
+	}
+}
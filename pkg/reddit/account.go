@@ -1,9 +1,8 @@
 package reddit
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
-	"net/http"
 )
 
 // Account contains user account information.
@@ -50,41 +49,31 @@ type Account struct {
 		UpgradeCookies         bool `json:"upgrade_cookies"`
 		YoutubeScraper         bool `json:"youtube_scraper"`
 	} `json:"features"`
-	GoldCreddits            int    `json:"gold_creddits"`
-	GoldExpiration          int    `json:"gold_expiration"`
-	HasVerifiedEmail        bool   `json:"has_verified_email"`
-	HideFromRobots          bool   `json:"hide_from_robots"`
-	ID                      string `json:"id"`
-	InBeta                  bool   `json:"in_beta"`
-	InboxCount              int    `json:"inbox_count"`
-	IsEmployee              bool   `json:"is_employee"`
-	IsGold                  bool   `json:"is_gold"`
-	IsMod                   bool   `json:"is_mod"`
-	IsSuspended             bool   `json:"is_suspended"`
-	LinkKarma               int    `json:"link_karma"`
-	Name                    string `json:"name"`
-	Over18                  bool   `json:"over_18"`
-	SuspensionExpirationUtc int    `json:"suspension_expiration_utc"`
+	GoldCreddits            int        `json:"gold_creddits"`
+	GoldExpiration          int        `json:"gold_expiration"`
+	HasVerifiedEmail        bool       `json:"has_verified_email"`
+	HideFromRobots          bool       `json:"hide_from_robots"`
+	IconImg                 string     `json:"icon_img"`
+	ID                      string     `json:"id"`
+	InBeta                  bool       `json:"in_beta"`
+	InboxCount              int        `json:"inbox_count"`
+	IsEmployee              bool       `json:"is_employee"`
+	IsGold                  bool       `json:"is_gold"`
+	IsMod                   bool       `json:"is_mod"`
+	IsSuspended             bool       `json:"is_suspended"`
+	LinkKarma               int        `json:"link_karma"`
+	Name                    string     `json:"name"`
+	Over18                  bool       `json:"over_18"`
+	Subreddit               *Subreddit `json:"subreddit"`
+	SuspensionExpirationUtc int        `json:"suspension_expiration_utc"`
 }
 
 // GetMe retrieves the user account for the currently authenticated user. Requires the 'identity' OAuth scope.
-func (c *Client) GetMe() (*Account, error) {
-	url := fmt.Sprintf("%s/api/v1/me", baseAuthURL)
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		return nil, err
-	}
-
-	req.Header.Add("User-Agent", c.userAgent)
-
-	resp, err := c.http.Do(req)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
+func (c *Client) GetMe(ctx context.Context) (*Account, error) {
+	url := fmt.Sprintf("%s/api/v1/me", c.baseAuthURL)
 
 	var account Account
-	err = json.NewDecoder(resp.Body).Decode(&account)
+	err := c.get(ctx, url, &account)
 	if err != nil {
 		return nil, err
 	}
@@ -1,10 +1,13 @@
 package reddit
 
 import (
+	"bytes"
 	"context"
 	"encoding/base64"
 	"errors"
 	"net/http"
+	"net/url"
+	"strconv"
 
 	"golang.org/x/oauth2"
 )
@@ -55,8 +58,9 @@ const (
 	// ScopeWikiRead allow user viewing of wiki pages.
 	ScopeWikiRead = "wikiread"
 
-	authURL  = "https://www.reddit.com/api/v1/authorize"
-	tokenURL = "https://www.reddit.com/api/v1/access_token"
+	authURL   = "https://www.reddit.com/api/v1/authorize"
+	tokenURL  = "https://www.reddit.com/api/v1/access_token"
+	revokeURL = "https://www.reddit.com/api/v1/revoke_token"
 )
 
 // NewAuthenticator generates a new authenticator with the supplied client, state, and requested scopes.
@@ -107,7 +111,7 @@ func (t *uaSetterTransport) RoundTrip(req *http.Request) (*http.Response, error)
 }
 
 // GetToken exchanges an authorization code for an access token.
-func (a *Authenticator) GetToken(state string, code string) (*oauth2.Token, error) {
+func (a *Authenticator) GetToken(ctx context.Context, state string, code string) (*oauth2.Token, error) {
 	if state != a.state {
 		return nil, errors.New("Invalid state")
 	}
@@ -116,7 +120,7 @@ func (a *Authenticator) GetToken(state string, code string) (*oauth2.Token, erro
 	// to the oauth2 context. https://github.com/golang/oauth2/issues/179
 	client := &http.Client{
 		Transport: &oauth2.Transport{
-			Source: a.config.TokenSource(oauth2.NoContext, &oauth2.Token{
+			Source: a.config.TokenSource(ctx, &oauth2.Token{
 				AccessToken: code,
 			}),
 			Base: &uaSetterTransport{
@@ -125,15 +129,55 @@ func (a *Authenticator) GetToken(state string, code string) (*oauth2.Token, erro
 			},
 		},
 	}
-	ctx := context.WithValue(oauth2.NoContext, oauth2.HTTPClient, client)
+	ctx = context.WithValue(ctx, oauth2.HTTPClient, client)
 
 	return a.config.Exchange(ctx, code)
 }
 
-// GetAuthClient generates a new authenticated client using the supplied access token.
-func (a *Authenticator) GetAuthClient(token *oauth2.Token) *Client {
-	return &Client{
-		http:      a.config.Client(oauth2.NoContext, token),
+// RefreshToken exchanges a refresh token, obtained with RequestPermanentToken, for a new access token.
+func (a *Authenticator) RefreshToken(ctx context.Context, refreshToken string) (*oauth2.Token, error) {
+	client := &http.Client{
+		Transport: &uaSetterTransport{
+			config:    a.config,
+			userAgent: a.userAgent,
+		},
+	}
+	ctx = context.WithValue(ctx, oauth2.HTTPClient, client)
+
+	return a.config.TokenSource(ctx, &oauth2.Token{RefreshToken: refreshToken}).Token()
+}
+
+// RevokeToken revokes a refresh token, along with the access tokens obtained with it.
+func (a *Authenticator) RevokeToken(ctx context.Context, refreshToken string) error {
+	data := url.Values{}
+	data.Set("token", refreshToken)
+	data.Set("token_type_hint", "refresh_token")
+	req, err := http.NewRequestWithContext(ctx, "POST", revokeURL, bytes.NewBufferString(data.Encode()))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Add("Content-Length", strconv.Itoa(len(data.Encode())))
+
+	transport := &uaSetterTransport{
+		config:    a.config,
 		userAgent: a.userAgent,
 	}
+	resp, err := transport.RoundTrip(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	return CheckResponse(resp)
+}
+
+// GetAuthClient generates a new authenticated client using the supplied access token, configured further by opts.
+func (a *Authenticator) GetAuthClient(token *oauth2.Token, opts ...Option) *Client {
+	opts = append([]Option{
+		WithHTTPClient(a.config.Client(oauth2.NoContext, token)),
+		WithUserAgent(a.userAgent),
+	}, opts...)
+	return New(opts...)
 }
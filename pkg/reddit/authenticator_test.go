@@ -1,8 +1,11 @@
 package reddit
 
 import (
+	"context"
+	"net/http"
 	"testing"
 
+	"github.com/jarcoal/httpmock"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -16,3 +19,44 @@ func TestGetAuthenticationURL(t *testing.T) {
 		"https://www.reddit.com/api/v1/authorize?client_id=client_id&redirect_uri=http%3A%2F%2Flocalhost%3A8000&response_type=code&scope=identity&state=123456789abcdef&duration=permanent",
 		authenticationURL)
 }
+
+func TestRefreshToken(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("POST", tokenURL, func(req *http.Request) (*http.Response, error) {
+		assert.Equal(t, "USER-AGENT", req.Header.Get("User-Agent"))
+		assert.NoError(t, req.ParseForm())
+		assert.Equal(t, "refresh_token", req.PostForm.Get("grant_type"))
+		assert.Equal(t, "refresh-token", req.PostForm.Get("refresh_token"))
+
+		resp := httpmock.NewStringResponse(200, `{"access_token": "access-token", "token_type": "bearer", "expires_in": 3600, "scope": "read"}`)
+		resp.Header.Set("Content-Type", "application/json")
+		return resp, nil
+	})
+
+	authenticator := NewAuthenticator("client_id", "client_secret", "http://localhost:8000", "USER-AGENT", "123456789abcdef", "read")
+	token, err := authenticator.RefreshToken(context.Background(), "refresh-token")
+	assert.NoError(t, err)
+	assert.Equal(t, "access-token", token.AccessToken)
+	// reddit does not send the refresh token again
+	assert.Equal(t, "refresh-token", token.RefreshToken)
+}
+
+func TestRevokeToken(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("POST", revokeURL, func(req *http.Request) (*http.Response, error) {
+		assert.Equal(t, "USER-AGENT", req.Header.Get("User-Agent"))
+		assert.NoError(t, req.ParseForm())
+		assert.Equal(t, "refresh-token", req.PostForm.Get("token"))
+		assert.Equal(t, "refresh_token", req.PostForm.Get("token_type_hint"))
+
+		return httpmock.NewStringResponse(204, ""), nil
+	})
+
+	authenticator := NewAuthenticator("client_id", "client_secret", "http://localhost:8000", "USER-AGENT", "123456789abcdef", "read")
+	err := authenticator.RevokeToken(context.Background(), "refresh-token")
+	assert.NoError(t, err)
+}
@@ -1,9 +1,8 @@
 package reddit
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
-	"net/http"
 )
 
 type Award struct {
@@ -17,22 +16,8 @@ type Award struct {
 }
 
 // GetMyTrophies retrieves a list of awards for the currently authenticated user. Requires the 'identity' OAuth scope.
-func (c *Client) GetMyTrophies() ([]*Award, error) {
-	url := fmt.Sprintf("%s/api/v1/me/trophies", baseAuthURL)
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		return nil, err
-	}
-
-	req.Header.Add("User-Agent", c.userAgent)
-
-	resp, err := c.http.Do(req)
-	if err != nil {
-		return nil, err
-	} else if resp.StatusCode >= 400 {
-		return nil, fmt.Errorf("HTTP Status Code: %d", resp.StatusCode)
-	}
-	defer resp.Body.Close()
+func (c *Client) GetMyTrophies(ctx context.Context) ([]*Award, error) {
+	url := fmt.Sprintf("%s/api/v1/me/trophies", c.baseAuthURL)
 
 	var trophyListing struct {
 		Kind string `json:"kind"`
@@ -41,7 +26,7 @@ func (c *Client) GetMyTrophies() ([]*Award, error) {
 		} `json:"data"`
 	}
 
-	err = json.NewDecoder(resp.Body).Decode(&trophyListing)
+	err := c.get(ctx, url, &trophyListing)
 	if err != nil {
 		return nil, err
 	}
@@ -1,6 +1,7 @@
 package reddit
 
 import (
+	"context"
 	"fmt"
 	"github.com/jarcoal/httpmock"
 	"github.com/stretchr/testify/assert"
@@ -13,7 +14,7 @@ func TestGetMyTrophies(t *testing.T) {
 	defer httpmock.DeactivateAndReset()
 
 	client := NoAuthClient
-	trophies, err := client.GetMyTrophies()
+	trophies, err := client.GetMyTrophies(context.Background())
 	assert.NoError(t, err)
 	assert.Equal(t, len(trophies), 1)
 }
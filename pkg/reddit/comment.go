@@ -1,71 +1,503 @@
 package reddit
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
 )
 
 // Comment is a response to a link or another comment.
 type Comment struct {
-	ApprovedBy          interface{}   `json:"approved_by"`
-	Archived            bool          `json:"archived"`
-	Author              string        `json:"author"`
-	AuthorFlairCSSClass interface{}   `json:"author_flair_css_class"`
-	AuthorFlairText     interface{}   `json:"author_flair_text"`
-	BannedBy            interface{}   `json:"banned_by"`
-	Body                string        `json:"body"`
-	BodyHTML            string        `json:"body_html"`
-	Controversiality    int           `json:"controversiality"`
-	Created             int           `json:"created"`
-	CreatedUtc          int           `json:"created_utc"`
-	Distinguished       interface{}   `json:"distinguished"`
-	Downs               int           `json:"downs"`
-	Edited              bool          `json:"edited"`
-	Gilded              int           `json:"gilded"`
-	ID                  string        `json:"id"`
-	Likes               interface{}   `json:"likes"`
-	LinkID              string        `json:"link_id"`
-	ModReports          []interface{} `json:"mod_reports"`
-	Name                string        `json:"name"`
-	NumReports          interface{}   `json:"num_reports"`
-	ParentID            string        `json:"parent_id"`
-	RemovalReason       interface{}   `json:"removal_reason"`
-	ReportReasons       interface{}   `json:"report_reasons"`
-	Replies             string        `json:"replies"`
-	Saved               bool          `json:"saved"`
-	Score               int           `json:"score"`
-	ScoreHidden         bool          `json:"score_hidden"`
-	Stickied            bool          `json:"stickied"`
-	Subreddit           string        `json:"subreddit"`
-	SubredditID         string        `json:"subreddit_id"`
-	Ups                 int           `json:"ups"`
-	UserReports         []interface{} `json:"user_reports"`
-}
-
-const commentType = "t1"
+	ApprovedBy          string   `json:"approved_by"`
+	Archived            bool     `json:"archived"`
+	Author              string   `json:"author"`
+	AuthorFlairCSSClass string   `json:"author_flair_css_class"`
+	AuthorFlairText     string   `json:"author_flair_text"`
+	BannedBy            string   `json:"banned_by"`
+	Body                string   `json:"body"`
+	BodyHTML            string   `json:"body_html"`
+	Controversiality    int      `json:"controversiality"`
+	Created             float64  `json:"created"`
+	CreatedUtc          float64  `json:"created_utc"`
+	Depth               int      `json:"depth"`
+	Distinguished       string   `json:"distinguished"`
+	Downs               int      `json:"downs"`
+	Edited              Edited   `json:"edited"`
+	Gilded              int      `json:"gilded"`
+	ID                  string   `json:"id"`
+	Likes               *bool    `json:"likes"`
+	LinkAuthor          string   `json:"link_author"`
+	LinkID              string   `json:"link_id"`
+	LinkPermalink       string   `json:"link_permalink"`
+	LinkTitle           string   `json:"link_title"`
+	LinkURL             string   `json:"link_url"`
+	ModReports          []Report `json:"mod_reports"`
+	Name                string   `json:"name"`
+	NumReports          int      `json:"num_reports"`
+	ParentID            string   `json:"parent_id"`
+	Permalink           string   `json:"permalink"`
+	RemovalReason       string   `json:"removal_reason"`
+	ReportReasons       []string `json:"report_reasons"`
+	Replies             Replies  `json:"replies"`
+	Saved               bool     `json:"saved"`
+	Score               int      `json:"score"`
+	ScoreHidden         bool     `json:"score_hidden"`
+	Stickied            bool     `json:"stickied"`
+	Subreddit           string   `json:"subreddit"`
+	SubredditID         string   `json:"subreddit_id"`
+	Ups                 int      `json:"ups"`
+	UserReports         []Report `json:"user_reports"`
+}
+
+// Report is a report of a comment, made by a moderator or by a number of users.
+type Report struct {
+	Reason    string
+	Moderator string
+	Count     int
+}
+
+// UnmarshalJSON decodes a report, which Reddit sends as an array of the reason followed by the moderator or the number of reports.
+func (r *Report) UnmarshalJSON(data []byte) error {
+	var fields []json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+
+	*r = Report{}
+	if len(fields) > 0 {
+		json.Unmarshal(fields[0], &r.Reason)
+	}
+	if len(fields) > 1 {
+		if json.Unmarshal(fields[1], &r.Moderator) != nil {
+			json.Unmarshal(fields[1], &r.Count)
+		}
+	}
+	return nil
+}
+
+// More stands for comments Reddit left out of a listing, GetMoreChildren loads them.
+// A More without Children continues a thread too deep to be sent, the replies of its parent have to be requested on their own.
+type More struct {
+	Count    int      `json:"count"`
+	Depth    int      `json:"depth"`
+	ID       string   `json:"id"`
+	Name     string   `json:"name"`
+	ParentID string   `json:"parent_id"`
+	Children []string `json:"children"`
+}
+
+// Edited is the time a comment was last edited, or zero if it never was.
+type Edited float64
+
+// UnmarshalJSON decodes the edited field, which Reddit sends as false for unedited comments and as a timestamp otherwise.
+func (e *Edited) UnmarshalJSON(data []byte) error {
+	if _, err := strconv.ParseBool(string(data)); err == nil {
+		*e = 0
+		return nil
+	}
+
+	var t float64
+	if err := json.Unmarshal(data, &t); err != nil {
+		return err
+	}
+	*e = Edited(t)
+	return nil
+}
+
+// Replies is a listing of comments, along with the stubs of the comments Reddit left out of it.
+type Replies struct {
+	Comments []*Comment
+	More     []*More
+}
+
+// UnmarshalJSON decodes a comment listing, which Reddit replaces with an empty string when there are no replies.
+func (r *Replies) UnmarshalJSON(data []byte) error {
+	*r = Replies{}
+	if string(data) == `""` || string(data) == "null" {
+		return nil
+	}
+
+	var listing commentListing
+	if err := json.Unmarshal(data, &listing); err != nil {
+		return err
+	}
+
+	return r.add(listing.Data.Children)
+}
+
+// add decodes the comments and stubs among things, other kinds are skipped.
+func (r *Replies) add(things []thing) error {
+	for _, child := range things {
+		switch child.Kind {
+		case commentType:
+			var comment Comment
+			if err := json.Unmarshal(child.Data, &comment); err != nil {
+				return err
+			}
+			r.Comments = append(r.Comments, &comment)
+		case moreType:
+			var more More
+			if err := json.Unmarshal(child.Data, &more); err != nil {
+				return err
+			}
+			r.More = append(r.More, &more)
+		}
+	}
+	return nil
+}
+
+// SkipReplies is returned by a WalkFunc to skip the replies of the comment it was called with.
+var SkipReplies = errors.New("skip replies")
+
+// WalkFunc is called by Walk for each comment, top-level comments being at depth 0.
+// Returning SkipReplies skips the replies of comment, any other error stops the walk.
+type WalkFunc func(comment *Comment, depth int) error
+
+// Walk calls fn for each comment of the tree depth first, in the order of the listing.
+// Replies deeper than maxDepth are skipped, a maxDepth below zero walks every level.
+func (r Replies) Walk(maxDepth int, fn WalkFunc) error {
+	return r.walk(0, maxDepth, fn)
+}
+
+func (r Replies) walk(depth int, maxDepth int, fn WalkFunc) error {
+	if maxDepth >= 0 && depth > maxDepth {
+		return nil
+	}
+
+	for _, comment := range r.Comments {
+		err := fn(comment, depth)
+		if err == SkipReplies {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		err = comment.Replies.walk(depth+1, maxDepth, fn)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type thing struct {
+	Kind string          `json:"kind"`
+	Data json.RawMessage `json:"data"`
+}
+
+type commentListing struct {
+	Kind string `json:"kind"`
+	Data struct {
+		Modhash  string      `json:"modhash"`
+		Children []thing     `json:"children"`
+		After    string      `json:"after"`
+		Before   interface{} `json:"before"`
+	} `json:"data"`
+}
+
+const (
+	commentType = "t1"
+	moreType    = "more"
+
+	// CommentSortBest orders comments by the confidence in their score, Reddit's default.
+	CommentSortBest = "confidence"
+	// CommentSortTop orders comments by score.
+	CommentSortTop = "top"
+	// CommentSortNew orders comments by creation time, newest first.
+	CommentSortNew = "new"
+	// CommentSortControversial orders comments by how divisive their votes are.
+	CommentSortControversial = "controversial"
+
+	// maxMoreChildren is the most comments GetMoreChildren can ask for at once.
+	maxMoreChildren = 100
+)
+
+var commentSorts = map[string]bool{
+	CommentSortBest:          true,
+	CommentSortTop:           true,
+	CommentSortNew:           true,
+	CommentSortControversial: true,
+}
+
+// CommentTreeOptions selects the comments of a link GetCommentTree loads. Empty fields use Reddit's defaults.
+type CommentTreeOptions struct {
+	// Sort is one of the CommentSort constants.
+	Sort string
+	// Depth is the number of levels loaded, 1 for top-level comments only. Zero loads as many as Reddit sends.
+	Depth int
+	// MoreRequests is the most requests made to load the comments Reddit left out of the listing.
+	// Zero loads none of them, below zero loads all of them.
+	MoreRequests int
+}
+
+// Validate reports whether the options hold values Reddit accepts.
+func (o CommentTreeOptions) Validate() error {
+	if o.Sort != "" && !commentSorts[o.Sort] {
+		return fmt.Errorf("invalid comment sort: %s", o.Sort)
+	}
+	if o.Depth < 0 {
+		return fmt.Errorf("invalid comment depth: %d", o.Depth)
+	}
+	return nil
+}
 
 // DeleteComment deletes a comment submitted by the currently authenticated user. Requires the 'edit' OAuth scope.
-func (c *Client) DeleteComment(commentID string) error {
-	return c.deleteThing(fmt.Sprintf("%s_%s", commentType, commentID))
+func (c *Client) DeleteComment(ctx context.Context, commentID string) error {
+	return c.deleteThing(ctx, fmt.Sprintf("%s_%s", commentType, commentID))
 }
 
 // EditCommentText edits the text of a comment by the currently authenticated user. Requires the 'edit' OAuth scope.
-func (c *Client) EditCommentText(commentID string, text string) error {
-	return c.editThingText(fmt.Sprintf("%s_%s", commentType, commentID), text)
+func (c *Client) EditCommentText(ctx context.Context, commentID string, text string) error {
+	return c.editThingText(ctx, fmt.Sprintf("%s_%s", commentType, commentID), text)
 }
 
-// GetLinkComments retrieves a listing of comments for the given link.
-func (c *Client) GetLinkComments(linkID string) ([]*Comment, error) {
-	url := fmt.Sprintf("%s/comments/%s", baseURL, linkID)
-	resp, err := c.http.Get(url)
+// GetLinkComments retrieves a listing of comments for the given link. Replies are nested in each comment.
+func (c *Client) GetLinkComments(ctx context.Context, linkID string) ([]*Comment, error) {
+	comments, err := c.GetCommentTree(ctx, linkID, CommentTreeOptions{})
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
 
-	return nil, nil
+	return comments.Comments, nil
+}
+
+// GetCommentTree retrieves the comments of the given link with their replies nested, and expands them with ExpandComments.
+// Stubs of the comments that were not loaded stay in the More fields.
+func (c *Client) GetCommentTree(ctx context.Context, linkID string, opts CommentTreeOptions) (Replies, error) {
+	err := opts.Validate()
+	if err != nil {
+		return Replies{}, err
+	}
+
+	comments, err := c.getComments(ctx, linkID, "", opts.Sort, opts.Depth)
+	if err != nil {
+		return Replies{}, err
+	}
+
+	err = c.ExpandComments(ctx, linkID, &comments, opts)
+	if err != nil {
+		return Replies{}, err
+	}
+
+	return comments, nil
+}
+
+// ExpandComments replaces the stubs of comments, the top-level comments of the given link, with the comments they stand for
+// within opts.MoreRequests, and drops replies deeper than opts.Depth.
+func (c *Client) ExpandComments(ctx context.Context, linkID string, comments *Replies, opts CommentTreeOptions) error {
+	err := opts.Validate()
+	if err != nil {
+		return err
+	}
+
+	loader := &treeLoader{
+		client: c,
+		linkID: linkID,
+		opts:   opts,
+		budget: opts.MoreRequests,
+	}
+	return loader.expand(ctx, comments, 0)
+}
+
+// GetMoreChildren loads the comments of the given link a More stub lists as its Children, at most 100 at once.
+// They are returned as a tree, whose top-level comments are replies to the parent of the stub.
+func (c *Client) GetMoreChildren(ctx context.Context, linkID string, children []string, sort string) (Replies, error) {
+	if len(children) > maxMoreChildren {
+		return Replies{}, fmt.Errorf("too many children: %d, at most %d can be loaded at once", len(children), maxMoreChildren)
+	}
+	if sort != "" && !commentSorts[sort] {
+		return Replies{}, fmt.Errorf("invalid comment sort: %s", sort)
+	}
+
+	params := url.Values{}
+	params.Set("api_type", "json")
+	params.Set("link_id", fmt.Sprintf("%s_%s", linkType, linkID))
+	params.Set("children", strings.Join(children, ","))
+	params.Set("limit_children", "false")
+	if sort != "" {
+		params.Set("sort", sort)
+	}
+
+	url := fmt.Sprintf("%s/api/morechildren.json?%s", c.baseURL, params.Encode())
+
+	var result struct {
+		JSON struct {
+			Errors [][]string `json:"errors"`
+			Data   struct {
+				Things []thing `json:"things"`
+			} `json:"data"`
+		} `json:"json"`
+	}
+	err := c.get(ctx, url, &result)
+	if err != nil {
+		return Replies{}, err
+	}
+	if len(result.JSON.Errors) > 0 {
+		return Replies{}, fmt.Errorf("morechildren: %v", result.JSON.Errors)
+	}
+
+	// the comments come as a flat list, nest them under their parents
+	var flat Replies
+	err = flat.add(result.JSON.Data.Things)
+	if err != nil {
+		return Replies{}, err
+	}
+
+	byName := make(map[string]*Comment)
+	for _, comment := range flat.Comments {
+		byName[comment.Name] = comment
+	}
+
+	var tree Replies
+	for _, comment := range flat.Comments {
+		if parent, ok := byName[comment.ParentID]; ok {
+			parent.Replies.Comments = append(parent.Replies.Comments, comment)
+		} else {
+			tree.Comments = append(tree.Comments, comment)
+		}
+	}
+	for _, more := range flat.More {
+		if parent, ok := byName[more.ParentID]; ok {
+			parent.Replies.More = append(parent.Replies.More, more)
+		} else {
+			tree.More = append(tree.More, more)
+		}
+	}
+
+	return tree, nil
+}
+
+// getComments requests the comments of the link, or only the replies to the comment focus when it is set.
+func (c *Client) getComments(ctx context.Context, linkID string, focus string, sort string, depth int) (Replies, error) {
+	params := url.Values{}
+	if focus != "" {
+		params.Set("comment", focus)
+	}
+	if sort != "" {
+		params.Set("sort", sort)
+	}
+	if depth > 0 {
+		params.Set("depth", strconv.Itoa(depth))
+	}
+
+	url := fmt.Sprintf("%s/comments/%s.json", c.baseURL, linkID)
+	if len(params) > 0 {
+		url += "?" + params.Encode()
+	}
+
+	// the response holds two listings, the link itself followed by its comments
+	var result []json.RawMessage
+	err := c.get(ctx, url, &result)
+	if err != nil {
+		return Replies{}, err
+	}
+	if len(result) < 2 {
+		return Replies{}, errors.New("comment listing missing from response")
+	}
+
+	var comments Replies
+	err = json.Unmarshal(result[1], &comments)
+	if err != nil {
+		return Replies{}, err
+	}
+
+	return comments, nil
+}
+
+// treeLoader replaces the stubs of a comment tree with the comments they stand for.
+type treeLoader struct {
+	client *Client
+	linkID string
+	opts   CommentTreeOptions
+	budget int
+}
+
+// expand loads the stubs of replies at depth, then those of the levels below.
+func (l *treeLoader) expand(ctx context.Context, replies *Replies, depth int) error {
+	if l.opts.Depth > 0 && depth >= l.opts.Depth {
+		replies.Comments, replies.More = nil, nil
+		return nil
+	}
+
+	var kept []*More
+	for len(replies.More) > 0 {
+		more := replies.More[0]
+		replies.More = replies.More[1:]
+		if l.budget == 0 {
+			kept = append(kept, more)
+			continue
+		}
+
+		loaded, err := l.load(ctx, more, depth)
+		if err != nil {
+			return err
+		}
+		replies.Comments = append(replies.Comments, loaded.Comments...)
+		replies.More = append(replies.More, loaded.More...)
+	}
+	replies.More = kept
+
+	for _, comment := range replies.Comments {
+		err := l.expand(ctx, &comment.Replies, depth+1)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// load requests the comments more stands for, when it lists more than can be loaded at once the rest is returned as a new stub.
+func (l *treeLoader) load(ctx context.Context, more *More, depth int) (Replies, error) {
+	l.budget--
+
+	if len(more.Children) == 0 {
+		if !strings.HasPrefix(more.ParentID, commentType+"_") {
+			return Replies{}, nil
+		}
+
+		levels := 0
+		if l.opts.Depth > 0 {
+			levels = l.opts.Depth - depth + 1
+		}
+		focus := strings.TrimPrefix(more.ParentID, commentType+"_")
+		thread, err := l.client.getComments(ctx, l.linkID, focus, l.opts.Sort, levels)
+		if err != nil {
+			return Replies{}, err
+		}
+		if len(thread.Comments) == 0 {
+			return Replies{}, nil
+		}
+		// the thread starts with the parent itself
+		return thread.Comments[0].Replies, nil
+	}
+
+	children := more.Children
+	var rest *More
+	if len(children) > maxMoreChildren {
+		remaining := *more
+		remaining.Children = children[maxMoreChildren:]
+		remaining.Count -= maxMoreChildren
+		rest = &remaining
+		children = children[:maxMoreChildren]
+	}
+
+	loaded, err := l.client.GetMoreChildren(ctx, l.linkID, children, l.opts.Sort)
+	if err != nil {
+		return Replies{}, err
+	}
+	if rest != nil {
+		loaded.More = append(loaded.More, rest)
+	}
+
+	return loaded, nil
 }
 
 // ReplyToComment creates a reply to the given comment. Requires the 'submit' OAuth scope.
-func (c *Client) ReplyToComment(commentID string, text string) error {
-	return c.commentOnThing(fmt.Sprintf("%s_%s", commentType, commentID), text)
+func (c *Client) ReplyToComment(ctx context.Context, commentID string, text string) error {
+	return c.commentOnThing(ctx, fmt.Sprintf("%s_%s", commentType, commentID), text)
 }
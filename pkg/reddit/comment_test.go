@@ -1,10 +1,15 @@
 package reddit
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
 	"github.com/jarcoal/httpmock"
 	"github.com/stretchr/testify/assert"
-	"testing"
 )
 
 func TestDeleteComment(t *testing.T) {
@@ -14,7 +19,7 @@ func TestDeleteComment(t *testing.T) {
 	defer httpmock.DeactivateAndReset()
 
 	client := NoAuthClient
-	err := client.DeleteComment("d9hthja")
+	err := client.DeleteComment(context.Background(), "d9hthja")
 	assert.NoError(t, err)
 }
 
@@ -25,7 +30,7 @@ func TestEditCommentText(t *testing.T) {
 	defer httpmock.DeactivateAndReset()
 
 	client := NoAuthClient
-	err := client.EditCommentText("d9hthja", "Hello World!")
+	err := client.EditCommentText(context.Background(), "d9hthja", "Hello World!")
 	assert.NoError(t, err)
 }
 
@@ -36,6 +41,128 @@ func TestReplyToComment(t *testing.T) {
 	defer httpmock.DeactivateAndReset()
 
 	client := NoAuthClient
-	err := client.ReplyToComment("d9hthja", "Hello World!")
+	err := client.ReplyToComment(context.Background(), "d9hthja", "Hello World!")
+	assert.NoError(t, err)
+}
+
+func TestGetLinkComments(t *testing.T) {
+	url := fmt.Sprintf("%s/comments/5ans3h.json", baseURL)
+	mockResponseFromFile(url, "test_data/comment/link_comments.json")
+	defer httpmock.DeactivateAndReset()
+
+	client := NoAuthClient
+	comments, err := client.GetLinkComments(context.Background(), "5ans3h")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(comments))
+	assert.Equal(t, "learner", comments[0].Author)
+	assert.Equal(t, 1, len(comments[0].Replies.Comments))
+	assert.Equal(t, "gopher", comments[0].Replies.Comments[0].Author)
+	assert.Equal(t, Edited(1477875000), comments[0].Replies.Comments[0].Edited)
+	assert.Equal(t, 0, len(comments[1].Replies.Comments))
+	assert.Equal(t, 1, len(comments[0].Replies.More))
+	assert.Equal(t, []string{"d9hthjd"}, comments[0].Replies.More[0].Children)
+}
+
+func TestGetCommentTree(t *testing.T) {
+	commentsURL := fmt.Sprintf("%s/comments/5ans3h.json", baseURL)
+	moreURL := fmt.Sprintf("%s/api/morechildren.json", baseURL)
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	comments, _ := ioutil.ReadFile("test_data/comment/link_comments.json")
+	thread, _ := ioutil.ReadFile("test_data/comment/thread.json")
+	more, _ := ioutil.ReadFile("test_data/comment/more_children.json")
+	httpmock.RegisterResponder("GET", commentsURL, func(req *http.Request) (*http.Response, error) {
+		assert.Equal(t, CommentSortNew, req.URL.Query().Get("sort"))
+		if req.URL.Query().Get("comment") == "d9hthje" {
+			return httpmock.NewStringResponse(200, string(thread)), nil
+		}
+		return httpmock.NewStringResponse(200, string(comments)), nil
+	})
+	httpmock.RegisterResponder("GET", moreURL, func(req *http.Request) (*http.Response, error) {
+		q := req.URL.Query()
+		assert.Equal(t, "t3_5ans3h", q.Get("link_id"))
+		assert.Equal(t, "d9hthjd", q.Get("children"))
+		assert.Equal(t, CommentSortNew, q.Get("sort"))
+		return httpmock.NewStringResponse(200, string(more)), nil
+	})
+
+	client := NoAuthClient
+	tree, err := client.GetCommentTree(context.Background(), "5ans3h", CommentTreeOptions{Sort: CommentSortNew, MoreRequests: -1})
 	assert.NoError(t, err)
+
+	var walked []string
+	err = tree.Walk(-1, func(comment *Comment, depth int) error {
+		walked = append(walked, fmt.Sprintf("%d:%s", depth, comment.ID))
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"0:d9hthja", "1:d9hthjb", "1:d9hthjd", "2:d9hthje", "3:d9hthjf", "0:d9hthjc"}, walked)
+	assert.Equal(t, 0, len(tree.Comments[0].Replies.More))
+	assert.Equal(t, 0, len(tree.Comments[0].Replies.Comments[1].Replies.Comments[0].Replies.More))
+	assert.Equal(t, 3, httpmock.GetTotalCallCount())
+
+	reply := tree.Comments[0].Replies.Comments[1]
+	assert.True(t, *reply.Likes)
+	assert.Equal(t, []Report{{Reason: "Spam", Count: 2}}, reply.UserReports)
+	assert.Equal(t, []Report{{Reason: "Off topic", Moderator: "golang_mod"}}, reply.ModReports)
+}
+
+func TestGetCommentTreeLimits(t *testing.T) {
+	url := fmt.Sprintf("%s/comments/5ans3h.json", baseURL)
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	comments, _ := ioutil.ReadFile("test_data/comment/link_comments.json")
+	httpmock.RegisterResponder("GET", url, func(req *http.Request) (*http.Response, error) {
+		assert.Equal(t, "1", req.URL.Query().Get("depth"))
+		return httpmock.NewStringResponse(200, string(comments)), nil
+	})
+
+	client := NoAuthClient
+	tree, err := client.GetCommentTree(context.Background(), "5ans3h", CommentTreeOptions{Depth: 1, MoreRequests: -1})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(tree.Comments))
+	assert.Equal(t, 0, len(tree.Comments[0].Replies.Comments))
+	assert.Equal(t, 0, len(tree.Comments[0].Replies.More))
+	assert.Equal(t, 1, httpmock.GetTotalCallCount())
+
+	_, err = client.GetCommentTree(context.Background(), "5ans3h", CommentTreeOptions{Sort: "oldest"})
+	assert.Error(t, err)
+
+	_, err = client.GetMoreChildren(context.Background(), "5ans3h", make([]string, 101), "")
+	assert.Error(t, err)
+}
+
+func TestWalk(t *testing.T) {
+	url := fmt.Sprintf("%s/comments/5ans3h.json", baseURL)
+	mockResponseFromFile(url, "test_data/comment/link_comments.json")
+	defer httpmock.DeactivateAndReset()
+
+	client := NoAuthClient
+	tree, err := client.GetCommentTree(context.Background(), "5ans3h", CommentTreeOptions{})
+	assert.NoError(t, err)
+
+	var walked []string
+	tree.Walk(0, func(comment *Comment, depth int) error {
+		walked = append(walked, comment.ID)
+		return nil
+	})
+	assert.Equal(t, []string{"d9hthja", "d9hthjc"}, walked)
+
+	walked = nil
+	tree.Walk(-1, func(comment *Comment, depth int) error {
+		walked = append(walked, comment.ID)
+		if comment.ID == "d9hthja" {
+			return SkipReplies
+		}
+		return nil
+	})
+	assert.Equal(t, []string{"d9hthja", "d9hthjc"}, walked)
+
+	stop := errors.New("stop")
+	err = tree.Walk(-1, func(comment *Comment, depth int) error {
+		return stop
+	})
+	assert.Equal(t, stop, err)
 }
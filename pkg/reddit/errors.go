@@ -0,0 +1,106 @@
+package reddit
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// The kinds of failures reported by Reddit. API methods return them wrapped in an *Error, test for them with errors.Is.
+var (
+	// ErrNotFound is returned when the requested thing does not exist.
+	ErrNotFound = errors.New("not found")
+	// ErrForbidden is returned when the client is not allowed to access the requested thing.
+	ErrForbidden = errors.New("forbidden")
+	// ErrRateLimited is returned when the client made too many requests, Error.Reset tells when to retry.
+	ErrRateLimited = errors.New("rate limited")
+	// ErrPrivate is returned for private subreddits the user is not a member of.
+	ErrPrivate = errors.New("private")
+	// ErrQuarantined is returned for quarantined subreddits the user did not opt into.
+	ErrQuarantined = errors.New("quarantined")
+	// ErrBanned is returned for banned subreddits.
+	ErrBanned = errors.New("banned")
+)
+
+// maxErrorBody is the most bytes of an error response kept in Error.Body.
+const maxErrorBody = 64 << 10
+
+// Error is an error response of the Reddit API.
+type Error struct {
+	// StatusCode is the HTTP status code of the response.
+	StatusCode int
+	// Reason is the reason Reddit gave for the failure, if any, ie: "private".
+	Reason string
+	// Body is the response body, truncated to 64KiB.
+	Body []byte
+	// Reset is when the rate limit resets, for ErrRateLimited.
+	Reset time.Time
+
+	kind error
+}
+
+func (e *Error) Error() string {
+	if e.kind != nil {
+		return fmt.Sprintf("HTTP Status Code: %d (%s)", e.StatusCode, e.kind.Error())
+	}
+	return fmt.Sprintf("HTTP Status Code: %d", e.StatusCode)
+}
+
+// Unwrap returns the kind of failure, one of the Err variables, or nil when it is not one of them.
+func (e *Error) Unwrap() error {
+	return e.kind
+}
+
+// CheckResponse returns an *Error for responses with a status code of 400 or above, and nil otherwise.
+// The body of error responses is read, but not closed.
+func CheckResponse(resp *http.Response) error {
+	if resp.StatusCode < 400 {
+		return nil
+	}
+
+	body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+	e := &Error{
+		StatusCode: resp.StatusCode,
+		Body:       body,
+	}
+
+	var reason struct {
+		Reason string `json:"reason"`
+	}
+	if json.Unmarshal(body, &reason) == nil {
+		e.Reason = reason.Reason
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests:
+		e.kind = ErrRateLimited
+		e.Reset = RateLimitReset(resp.Header, time.Now())
+	case e.Reason == "private":
+		e.kind = ErrPrivate
+	case e.Reason == "quarantined":
+		e.kind = ErrQuarantined
+	case e.Reason == "banned":
+		e.kind = ErrBanned
+	case resp.StatusCode == http.StatusForbidden:
+		e.kind = ErrForbidden
+	case resp.StatusCode == http.StatusNotFound:
+		e.kind = ErrNotFound
+	}
+
+	return e
+}
+
+// RateLimitReset returns when the rate limit resets according to the headers of a rejected response, a minute from now when they don't tell.
+func RateLimitReset(h http.Header, now time.Time) time.Time {
+	for _, key := range []string{"X-Ratelimit-Reset", "Retry-After"} {
+		if seconds, err := strconv.ParseFloat(h.Get(key), 64); err == nil && seconds >= 0 {
+			return now.Add(time.Duration(seconds * float64(time.Second)))
+		}
+	}
+	return now.Add(time.Minute)
+}
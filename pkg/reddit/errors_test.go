@@ -0,0 +1,103 @@
+package reddit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckResponse(t *testing.T) {
+	tests := []struct {
+		status int
+		body   string
+		kind   error
+		reason string
+	}{
+		{403, `{"reason": "private", "message": "Forbidden", "error": 403}`, ErrPrivate, "private"},
+		{403, `{"reason": "quarantined", "quarantine_message": "", "error": 403}`, ErrQuarantined, "quarantined"},
+		{404, `{"reason": "banned", "message": "Not Found", "error": 404}`, ErrBanned, "banned"},
+		{403, `{"message": "Forbidden", "error": 403}`, ErrForbidden, ""},
+		{404, `{"message": "Not Found", "error": 404}`, ErrNotFound, ""},
+		{404, `<html>not found</html>`, ErrNotFound, ""},
+		{500, `upstream error`, nil, ""},
+	}
+
+	for _, test := range tests {
+		err := CheckResponse(httpmock.NewStringResponse(test.status, test.body))
+
+		var apiErr *Error
+		assert.True(t, errors.As(err, &apiErr), test.body)
+		assert.Equal(t, test.status, apiErr.StatusCode)
+		assert.Equal(t, test.reason, apiErr.Reason)
+		assert.Equal(t, test.body, string(apiErr.Body))
+		assert.Equal(t, test.kind, errors.Unwrap(err), test.body)
+	}
+
+	assert.NoError(t, CheckResponse(httpmock.NewStringResponse(200, "{}")))
+}
+
+func TestCheckResponseRateLimited(t *testing.T) {
+	resp := httpmock.NewStringResponse(429, "Too Many Requests")
+	resp.Header.Set("X-Ratelimit-Reset", "30")
+
+	before := time.Now()
+	err := CheckResponse(resp)
+	assert.True(t, errors.Is(err, ErrRateLimited))
+
+	var apiErr *Error
+	assert.True(t, errors.As(err, &apiErr))
+	assert.WithinDuration(t, before.Add(30*time.Second), apiErr.Reset, time.Second)
+	assert.Equal(t, "HTTP Status Code: 429 (rate limited)", err.Error())
+}
+
+func TestRateLimitReset(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	tests := []struct {
+		header http.Header
+		reset  time.Time
+	}{
+		{http.Header{"X-Ratelimit-Reset": {"30"}, "Retry-After": {"10"}}, now.Add(30 * time.Second)},
+		{http.Header{"X-Ratelimit-Reset": {"0.5"}}, now.Add(500 * time.Millisecond)},
+		{http.Header{"Retry-After": {"10"}}, now.Add(10 * time.Second)},
+		{http.Header{"X-Ratelimit-Reset": {"soon"}}, now.Add(time.Minute)},
+		{http.Header{}, now.Add(time.Minute)},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.reset, RateLimitReset(test.header, now), "%v", test.header)
+	}
+}
+
+func TestGetLinksPrivate(t *testing.T) {
+	url := fmt.Sprintf("%s/r/secret/hot.json", baseURL)
+	httpmock.Activate()
+	httpmock.RegisterResponder("GET", url, httpmock.NewStringResponder(403, `{"reason": "private", "message": "Forbidden", "error": 403}`))
+	defer httpmock.DeactivateAndReset()
+
+	client := NoAuthClient
+	_, err := client.GetHotLinks(context.Background(), "secret")
+	assert.True(t, errors.Is(err, ErrPrivate))
+}
+
+func TestRequestsCarryContext(t *testing.T) {
+	url := fmt.Sprintf("%s/r/news/hot.json", baseURL)
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	type key struct{}
+	var got interface{}
+	httpmock.RegisterResponder("GET", url, func(req *http.Request) (*http.Response, error) {
+		got = req.Context().Value(key{})
+		return httpmock.NewStringResponse(200, `{"kind": "Listing", "data": {"children": []}}`), nil
+	})
+
+	client := NoAuthClient
+	_, err := client.GetHotLinks(context.WithValue(context.Background(), key{}, "value"), "news")
+	assert.NoError(t, err)
+	assert.Equal(t, "value", got)
+}
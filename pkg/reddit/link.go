@@ -1,12 +1,9 @@
 package reddit
 
 import (
-	"bytes"
-	"encoding/json"
+	"context"
 	"fmt"
-	"net/http"
 	"net/url"
-	"strconv"
 )
 
 // Link contains information about a link.
@@ -104,86 +101,100 @@ type linkListing struct {
 }
 
 // CommentOnLink posts a top-level comment to the given link. Requires the 'submit' OAuth scope.
-func (c *Client) CommentOnLink(linkID string, text string) error {
-	return c.commentOnThing(fmt.Sprintf("%s_%s", linkType, linkID), text)
+func (c *Client) CommentOnLink(ctx context.Context, linkID string, text string) error {
+	return c.commentOnThing(ctx, fmt.Sprintf("%s_%s", linkType, linkID), text)
 }
 
 // DeleteLink deletes a link submitted by the currently authenticated user. Requires the 'edit' OAuth scope.
-func (c *Client) DeleteLink(linkID string) error {
-	return c.deleteThing(fmt.Sprintf("%s_%s", linkType, linkID))
+func (c *Client) DeleteLink(ctx context.Context, linkID string) error {
+	return c.deleteThing(ctx, fmt.Sprintf("%s_%s", linkType, linkID))
 }
 
 // EditLinkText edits the text of a self post by the currently authenticated user. Requires the 'edit' OAuth scope.
-func (c *Client) EditLinkText(linkID string, text string) error {
-	return c.editThingText(fmt.Sprintf("%s_%s", linkType, linkID), text)
+func (c *Client) EditLinkText(ctx context.Context, linkID string, text string) error {
+	return c.editThingText(ctx, fmt.Sprintf("%s_%s", linkType, linkID), text)
 }
 
 // GetHotLinks retrieves a listing of hot links.
-func (c *Client) GetHotLinks(subreddit string) ([]*Link, error) {
-	return c.getLinks(subreddit, "hot")
+func (c *Client) GetHotLinks(ctx context.Context, subreddit string) ([]*Link, error) {
+	return c.getLinks(ctx, subreddit, SortHot)
 }
 
 // GetNewLinks retrieves a listing of new links.
-func (c *Client) GetNewLinks(subreddit string) ([]*Link, error) {
-	return c.getLinks(subreddit, "new")
+func (c *Client) GetNewLinks(ctx context.Context, subreddit string) ([]*Link, error) {
+	return c.getLinks(ctx, subreddit, SortNew)
 }
 
 // GetTopLinks retrieves a listing of top links.
-func (c *Client) GetTopLinks(subreddit string) ([]*Link, error) {
-	return c.getLinks(subreddit, "top")
+func (c *Client) GetTopLinks(ctx context.Context, subreddit string) ([]*Link, error) {
+	return c.getLinks(ctx, subreddit, SortTop)
 }
 
-// HideLink removes the given link from the user's default view of subreddit listings. Requires the 'report' OAuth scope.
-func (c *Client) HideLink(linkID string) error {
-	data := url.Values{}
-	data.Set("id", fmt.Sprintf("%s_%s", linkType, linkID))
-	url := fmt.Sprintf("%s/api/hide", baseAuthURL)
-	req, err := http.NewRequest("POST", url, bytes.NewBufferString(data.Encode()))
-
-	if err != nil {
-		return err
-	}
-
-	req.Header.Add("User-Agent", c.userAgent)
-	req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
-	req.Header.Add("Content-Length", strconv.Itoa(len(data.Encode())))
+// GetRisingLinks retrieves a listing of rising links.
+func (c *Client) GetRisingLinks(ctx context.Context, subreddit string) ([]*Link, error) {
+	return c.getLinks(ctx, subreddit, SortRising)
+}
 
-	resp, err := c.http.Do(req)
-	if err != nil {
-		return err
-	} else if resp.StatusCode >= 400 {
-		return fmt.Errorf("HTTP Status Code: %d", resp.StatusCode)
-	}
-	defer resp.Body.Close()
+// GetControversialLinks retrieves a listing of controversial links.
+func (c *Client) GetControversialLinks(ctx context.Context, subreddit string) ([]*Link, error) {
+	return c.getLinks(ctx, subreddit, SortControversial)
+}
 
-	return nil
+// GetBestLinks retrieves the best links of the front page of the currently authenticated user.
+func (c *Client) GetBestLinks(ctx context.Context) ([]*Link, error) {
+	return c.getLinks(ctx, "", SortBest)
 }
 
-func (c *Client) getLinks(subreddit string, sort string) ([]*Link, error) {
-	url := fmt.Sprintf("%s/r/%s/%s.json", baseURL, subreddit, sort)
-	req, err := http.NewRequest("GET", url, nil)
+// GetLinks retrieves a page of the subreddit listing sorted by sort, one of the Sort constants.
+// An empty subreddit is the front page.
+func (c *Client) GetLinks(ctx context.Context, subreddit string, sort string, opts ListingOptions) (*ListingPage, error) {
+	if !linkSorts[sort] {
+		return nil, fmt.Errorf("invalid listing sort: %s", sort)
+	}
+	err := opts.Validate()
 	if err != nil {
 		return nil, err
 	}
 
-	req.Header.Add("User-Agent", c.userAgent)
+	path := fmt.Sprintf("/%s.json", sort)
+	if subreddit != "" {
+		path = fmt.Sprintf("/r/%s/%s.json", subreddit, sort)
+	}
 
-	resp, err := c.http.Do(req)
-	if err != nil {
-		return nil, err
+	url := fmt.Sprintf("%s%s", c.baseURL, path)
+	if params := opts.values(); len(params) > 0 {
+		url += "?" + params.Encode()
 	}
-	defer resp.Body.Close()
 
 	var result linkListing
-	err = json.NewDecoder(resp.Body).Decode(&result)
+	err = c.get(ctx, url, &result)
 	if err != nil {
 		return nil, err
 	}
 
-	var links []*Link
-	for _, link := range result.Data.Children {
-		links = append(links, &link.Data)
+	page := &ListingPage{After: result.Data.After}
+	if before, ok := result.Data.Before.(string); ok {
+		page.Before = before
+	}
+	for i := range result.Data.Children {
+		page.Links = append(page.Links, &result.Data.Children[i].Data)
+	}
+
+	return page, nil
+}
+
+// HideLink removes the given link from the user's default view of subreddit listings. Requires the 'report' OAuth scope.
+func (c *Client) HideLink(ctx context.Context, linkID string) error {
+	data := url.Values{}
+	data.Set("id", fmt.Sprintf("%s_%s", linkType, linkID))
+	return c.postForm(ctx, fmt.Sprintf("%s/api/hide", c.baseAuthURL), data)
+}
+
+func (c *Client) getLinks(ctx context.Context, subreddit string, sort string) ([]*Link, error) {
+	page, err := c.GetLinks(ctx, subreddit, sort, ListingOptions{})
+	if err != nil {
+		return nil, err
 	}
 
-	return links, nil
+	return page.Links, nil
 }
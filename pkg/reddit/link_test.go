@@ -1,6 +1,7 @@
 package reddit
 
 import (
+	"context"
 	"fmt"
 	"github.com/jarcoal/httpmock"
 	"github.com/stretchr/testify/assert"
@@ -14,7 +15,7 @@ func TestDeleteLink(t *testing.T) {
 	defer httpmock.DeactivateAndReset()
 
 	client := NoAuthClient
-	err := client.DeleteLink("5ans3h")
+	err := client.DeleteLink(context.Background(), "5ans3h")
 	assert.NoError(t, err)
 }
 
@@ -25,7 +26,7 @@ func TestCommentOnLink(t *testing.T) {
 	defer httpmock.DeactivateAndReset()
 
 	client := NoAuthClient
-	err := client.CommentOnLink("5ans3h", "Hello World!")
+	err := client.CommentOnLink(context.Background(), "5ans3h", "Hello World!")
 	assert.NoError(t, err)
 }
 
@@ -36,7 +37,7 @@ func TestEditLinkText(t *testing.T) {
 	defer httpmock.DeactivateAndReset()
 
 	client := NoAuthClient
-	err := client.EditLinkText("5ans3h", "Hello World!")
+	err := client.EditLinkText(context.Background(), "5ans3h", "Hello World!")
 	assert.NoError(t, err)
 }
 
@@ -46,7 +47,7 @@ func TestGetHotLinks(t *testing.T) {
 	defer httpmock.DeactivateAndReset()
 
 	client := NoAuthClient
-	links, err := client.GetHotLinks("news")
+	links, err := client.GetHotLinks(context.Background(), "news")
 	assert.NoError(t, err)
 	assert.Equal(t, len(links), 3)
 }
@@ -57,7 +58,7 @@ func TestGetNewLinks(t *testing.T) {
 	defer httpmock.DeactivateAndReset()
 
 	client := NoAuthClient
-	links, err := client.GetNewLinks("news")
+	links, err := client.GetNewLinks(context.Background(), "news")
 	assert.NoError(t, err)
 	assert.Equal(t, len(links), 3)
 }
@@ -68,7 +69,7 @@ func TestGetTopLinks(t *testing.T) {
 	defer httpmock.DeactivateAndReset()
 
 	client := NoAuthClient
-	links, err := client.GetTopLinks("news")
+	links, err := client.GetTopLinks(context.Background(), "news")
 	assert.NoError(t, err)
 	assert.Equal(t, len(links), 3)
 }
@@ -80,6 +81,28 @@ func TestHideLink(t *testing.T) {
 	defer httpmock.DeactivateAndReset()
 
 	client := NoAuthClient
-	err := client.HideLink("5ans3h")
+	err := client.HideLink(context.Background(), "5ans3h")
 	assert.NoError(t, err)
 }
+
+func TestGetRisingLinks(t *testing.T) {
+	url := fmt.Sprintf("%s/r/news/rising.json", baseURL)
+	mockResponseFromFile(url, "test_data/link/new_links.json")
+	defer httpmock.DeactivateAndReset()
+
+	client := NoAuthClient
+	links, err := client.GetRisingLinks(context.Background(), "news")
+	assert.NoError(t, err)
+	assert.Equal(t, len(links), 3)
+}
+
+func TestGetControversialLinks(t *testing.T) {
+	url := fmt.Sprintf("%s/r/news/controversial.json", baseURL)
+	mockResponseFromFile(url, "test_data/link/top_links.json")
+	defer httpmock.DeactivateAndReset()
+
+	client := NoAuthClient
+	links, err := client.GetControversialLinks(context.Background(), "news")
+	assert.NoError(t, err)
+	assert.Equal(t, len(links), 3)
+}
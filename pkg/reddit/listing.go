@@ -0,0 +1,172 @@
+package reddit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+)
+
+const (
+	// SortHot orders links by hotness.
+	SortHot = "hot"
+	// SortNew orders links by submission time, newest first.
+	SortNew = "new"
+	// SortTop orders links by score.
+	SortTop = "top"
+	// SortRising orders links by how fast they gain attention.
+	SortRising = "rising"
+	// SortControversial orders links by how divided votes are.
+	SortControversial = "controversial"
+	// SortBest orders the front page by what the user is likely to enjoy.
+	SortBest = "best"
+
+	// maxListingLimit is the most links Reddit returns in a single page.
+	maxListingLimit = 100
+)
+
+var linkSorts = map[string]bool{
+	SortHot:           true,
+	SortNew:           true,
+	SortTop:           true,
+	SortRising:        true,
+	SortControversial: true,
+	SortBest:          true,
+}
+
+// ListingOptions selects a page of a listing. Empty fields use Reddit's defaults.
+type ListingOptions struct {
+	// Limit is the number of links of the page, up to 100.
+	Limit int
+	// After asks for the page following the thing with this fullname, as found in ListingPage.After.
+	After string
+	// Before asks for the page preceding the thing with this fullname, as found in ListingPage.Before.
+	Before string
+	// Time is one of the Time constants, for the top and controversial sorts.
+	Time string
+	// Count is the number of links already seen in the listing, Reddit uses it to number the next ones.
+	Count int
+}
+
+// Validate reports whether the options hold values Reddit accepts.
+func (o ListingOptions) Validate() error {
+	if o.Limit < 0 || o.Limit > maxListingLimit {
+		return fmt.Errorf("invalid listing limit: %d, expected up to %d", o.Limit, maxListingLimit)
+	}
+	if o.After != "" && o.Before != "" {
+		return errors.New("invalid listing options: only one of after and before may be set")
+	}
+	if o.Time != "" && !times[o.Time] {
+		return fmt.Errorf("invalid listing time: %s", o.Time)
+	}
+	if o.Count < 0 {
+		return fmt.Errorf("invalid listing count: %d", o.Count)
+	}
+	return nil
+}
+
+func (o ListingOptions) values() url.Values {
+	params := url.Values{}
+	if o.Limit > 0 {
+		params.Set("limit", strconv.Itoa(o.Limit))
+	}
+	if o.After != "" {
+		params.Set("after", o.After)
+	}
+	if o.Before != "" {
+		params.Set("before", o.Before)
+	}
+	if o.Time != "" {
+		params.Set("t", o.Time)
+	}
+	if o.Count > 0 {
+		params.Set("count", strconv.Itoa(o.Count))
+	}
+	return params
+}
+
+// ListingPage is a page of links, with the cursors of the pages around it.
+type ListingPage struct {
+	Links []*Link
+	// After is the cursor of the next page, empty on the last one.
+	After string
+	// Before is the cursor of the previous page, empty on the first one.
+	Before string
+}
+
+// LinkIterator walks the links of a listing, fetching its pages as they are needed.
+//
+//	it := client.IterateLinks(ctx, "golang", reddit.SortNew, reddit.ListingOptions{Limit: 100})
+//	for it.Next() {
+//		fmt.Println(it.Link().Title)
+//	}
+//	if err := it.Err(); err != nil {
+//		...
+//	}
+type LinkIterator struct {
+	ctx       context.Context
+	client    *Client
+	subreddit string
+	sort      string
+	opts      ListingOptions
+
+	page *ListingPage
+	i    int
+	last bool
+	err  error
+}
+
+// IterateLinks returns an iterator over the links of the subreddit listing sorted by sort, starting at the page opts selects.
+// Pages are requested with ctx.
+func (c *Client) IterateLinks(ctx context.Context, subreddit string, sort string, opts ListingOptions) *LinkIterator {
+	return &LinkIterator{
+		ctx:       ctx,
+		client:    c,
+		subreddit: subreddit,
+		sort:      sort,
+		opts:      opts,
+	}
+}
+
+// Next advances to the next link, it returns false at the end of the listing or on error.
+func (it *LinkIterator) Next() bool {
+	if it.err != nil {
+		return false
+	}
+
+	for it.page == nil || it.i >= len(it.page.Links)-1 {
+		if it.last {
+			return false
+		}
+
+		page, err := it.client.GetLinks(it.ctx, it.subreddit, it.sort, it.opts)
+		if err != nil {
+			it.err = err
+			return false
+		}
+
+		it.page, it.i = page, -1
+		it.opts.Count += len(page.Links)
+		it.opts.After, it.opts.Before = page.After, ""
+		it.last = page.After == "" || len(page.Links) == 0
+	}
+
+	it.i++
+	return true
+}
+
+// Link returns the current link.
+func (it *LinkIterator) Link() *Link {
+	return it.page.Links[it.i]
+}
+
+// Page returns the page of the current link, its After cursor resumes the listing past it.
+func (it *LinkIterator) Page() *ListingPage {
+	return it.page
+}
+
+// Err returns the error that stopped the iteration, if any.
+func (it *LinkIterator) Err() error {
+	return it.err
+}
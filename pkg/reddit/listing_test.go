@@ -0,0 +1,108 @@
+package reddit
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetLinks(t *testing.T) {
+	url := fmt.Sprintf("%s/r/news/top.json", baseURL)
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	response, _ := ioutil.ReadFile("test_data/link/top_links.json")
+	httpmock.RegisterResponder("GET", url, func(req *http.Request) (*http.Response, error) {
+		q := req.URL.Query()
+		assert.Equal(t, "50", q.Get("limit"))
+		assert.Equal(t, "t3_57rj95", q.Get("after"))
+		assert.Equal(t, "week", q.Get("t"))
+		assert.Equal(t, "25", q.Get("count"))
+		return httpmock.NewStringResponse(200, string(response)), nil
+	})
+
+	client := NoAuthClient
+	page, err := client.GetLinks(context.Background(), "news", SortTop, ListingOptions{Limit: 50, After: "t3_57rj95", Time: TimeWeek, Count: 25})
+	assert.NoError(t, err)
+	assert.Len(t, page.Links, 3)
+	assert.Equal(t, "t3_57wi3j", page.After)
+	assert.Equal(t, "", page.Before)
+	assert.NotEqual(t, page.Links[0].ID, page.Links[1].ID)
+}
+
+func TestGetLinksFrontPage(t *testing.T) {
+	url := fmt.Sprintf("%s/best.json", baseURL)
+	mockResponseFromFile(url, "test_data/link/hot_links.json")
+	defer httpmock.DeactivateAndReset()
+
+	client := NoAuthClient
+	links, err := client.GetBestLinks(context.Background())
+	assert.NoError(t, err)
+	assert.Len(t, links, 3)
+}
+
+func TestGetLinksInvalidOptions(t *testing.T) {
+	client := NoAuthClient
+
+	_, err := client.GetLinks(context.Background(), "news", "oldest", ListingOptions{})
+	assert.Error(t, err)
+
+	_, err = client.GetLinks(context.Background(), "news", SortTop, ListingOptions{Limit: 101})
+	assert.Error(t, err)
+
+	_, err = client.GetLinks(context.Background(), "news", SortTop, ListingOptions{After: "t3_a", Before: "t3_b"})
+	assert.Error(t, err)
+
+	_, err = client.GetLinks(context.Background(), "news", SortTop, ListingOptions{Time: "decade"})
+	assert.Error(t, err)
+}
+
+func TestIterateLinks(t *testing.T) {
+	url := fmt.Sprintf("%s/r/news/new.json", baseURL)
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	first, _ := ioutil.ReadFile("test_data/link/hot_links.json")
+	last, _ := ioutil.ReadFile("test_data/link/last_links.json")
+	var requests []string
+	httpmock.RegisterResponder("GET", url, func(req *http.Request) (*http.Response, error) {
+		requests = append(requests, req.URL.RawQuery)
+		if req.URL.Query().Get("after") == "t3_57rj95" {
+			assert.Equal(t, "3", req.URL.Query().Get("count"))
+			return httpmock.NewStringResponse(200, string(last)), nil
+		}
+		return httpmock.NewStringResponse(200, string(first)), nil
+	})
+
+	client := NoAuthClient
+	it := client.IterateLinks(context.Background(), "news", SortNew, ListingOptions{})
+
+	var ids []string
+	for it.Next() {
+		ids = append(ids, it.Link().ID)
+		// pages are only fetched once their links are reached
+		assert.Len(t, requests, 1+len(ids)/4)
+	}
+	assert.NoError(t, it.Err())
+	assert.Len(t, ids, 4)
+	assert.Equal(t, "57rk01", ids[3])
+	assert.Len(t, requests, 2)
+	assert.Equal(t, "t3_57rk01", it.Page().Before)
+}
+
+func TestIterateLinksError(t *testing.T) {
+	url := fmt.Sprintf("%s/r/news/rising.json", baseURL)
+	httpmock.Activate()
+	httpmock.RegisterResponder("GET", url, httpmock.NewStringResponder(500, ""))
+	defer httpmock.DeactivateAndReset()
+
+	client := NoAuthClient
+	it := client.IterateLinks(context.Background(), "news", SortRising, ListingOptions{})
+	assert.False(t, it.Next())
+	assert.Error(t, it.Err())
+}
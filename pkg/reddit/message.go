@@ -1,53 +1,110 @@
 package reddit
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
 )
 
 // Message is a private message between users.
 type Message struct {
-	Author           string      `json:"author"`
-	Body             string      `json:"body"`
-	BodyHTML         string      `json:"body_html"`
-	Context          string      `json:"context"`
-	Created          int         `json:"created"`
-	CreatedUtc       int         `json:"created_utc"`
-	Dest             string      `json:"dest"`
-	Distinguished    string      `json:"distinguished"`
-	FirstMessage     interface{} `json:"first_message"`
-	FirstMessageName interface{} `json:"first_message_name"`
-	ID               string      `json:"id"`
-	Name             string      `json:"name"`
-	New              bool        `json:"new"`
-	ParentID         interface{} `json:"parent_id"`
-	Replies          string      `json:"replies"`
-	Subject          string      `json:"subject"`
-	Subreddit        interface{} `json:"subreddit"`
-	WasComment       bool        `json:"was_comment"`
+	Author           string         `json:"author"`
+	Body             string         `json:"body"`
+	BodyHTML         string         `json:"body_html"`
+	Context          string         `json:"context"`
+	Created          float64        `json:"created"`
+	CreatedUtc       float64        `json:"created_utc"`
+	Dest             string         `json:"dest"`
+	Distinguished    string         `json:"distinguished"`
+	FirstMessage     interface{}    `json:"first_message"`
+	FirstMessageName string         `json:"first_message_name"`
+	ID               string         `json:"id"`
+	LinkTitle        string         `json:"link_title"`
+	Name             string         `json:"name"`
+	New              bool           `json:"new"`
+	ParentID         interface{}    `json:"parent_id"`
+	Replies          MessageReplies `json:"replies"`
+	Subject          string         `json:"subject"`
+	Subreddit        string         `json:"subreddit"`
+	WasComment       bool           `json:"was_comment"`
+}
+
+// MessageReplies is the listing of replies to a message.
+type MessageReplies []*Message
+
+// UnmarshalJSON decodes a message listing, which Reddit replaces with an empty string when there are no replies.
+func (r *MessageReplies) UnmarshalJSON(data []byte) error {
+	if string(data) == `""` || string(data) == "null" {
+		*r = nil
+		return nil
+	}
+
+	messages, err := decodeMessageListing(data)
+	if err != nil {
+		return err
+	}
+	*r = messages
+
+	return nil
+}
+
+// decodeMessageListing decodes the messages of a listing. Comment replies in the inbox come with the same fields.
+func decodeMessageListing(data []byte) ([]*Message, error) {
+	var listing commentListing
+	if err := json.Unmarshal(data, &listing); err != nil {
+		return nil, err
+	}
+
+	var messages []*Message
+	for _, child := range listing.Data.Children {
+		if child.Kind != messageType && child.Kind != commentType {
+			continue
+		}
+		var message Message
+		if err := json.Unmarshal(child.Data, &message); err != nil {
+			return nil, err
+		}
+		messages = append(messages, &message)
+	}
+
+	return messages, nil
 }
 
 const messageType string = "t4"
 
 // GetInboxMessages retrieves a list of messages in the user's inbox. Requires the 'privatemessages' OAuth scope.
-func (c *Client) GetInboxMessages() ([]*Message, error) {
-	return c.getMessages("inbox")
+func (c *Client) GetInboxMessages(ctx context.Context) ([]*Message, error) {
+	return c.getMessages(ctx, "inbox")
 }
 
 // GetUnreadMessages retrieves a list of unread messages in the user's inbox. Requires the 'privatemessages' OAuth scope.
-func (c *Client) GetUnreadMessages() ([]*Message, error) {
-	return c.getMessages("unread")
+func (c *Client) GetUnreadMessages(ctx context.Context) ([]*Message, error) {
+	return c.getMessages(ctx, "unread")
 }
 
 // GetSentMessages retrieves a list of messages sent by the user. Requires the 'privatemessages' OAuth scope.
-func (c *Client) GetSentMessages() ([]*Message, error) {
-	return c.getMessages("sent")
+func (c *Client) GetSentMessages(ctx context.Context) ([]*Message, error) {
+	return c.getMessages(ctx, "sent")
+}
+
+// GetMentionMessages retrieves a list of comments mentioning the user. Requires the 'privatemessages' OAuth scope.
+func (c *Client) GetMentionMessages(ctx context.Context) ([]*Message, error) {
+	return c.getMessages(ctx, "mentions")
 }
 
 // ReplyToMessage creates a reply to a message sent to the user. Requires the 'privatemessages' OAuth scope.
-func (c *Client) ReplyToMessage(messageID string, text string) error {
-	return c.commentOnThing(fmt.Sprintf("%s_%s", messageType, messageID), text)
+func (c *Client) ReplyToMessage(ctx context.Context, messageID string, text string) error {
+	return c.commentOnThing(ctx, fmt.Sprintf("%s_%s", messageType, messageID), text)
 }
 
-func (c *Client) getMessages(where string) ([]*Message, error) {
-	return nil, nil
+func (c *Client) getMessages(ctx context.Context, where string) ([]*Message, error) {
+	url := fmt.Sprintf("%s/message/%s.json", c.baseAuthURL, where)
+
+	var body json.RawMessage
+	err := c.get(ctx, url, &body)
+	if err != nil {
+		return nil, err
+	}
+
+	return decodeMessageListing(body)
 }
@@ -1,6 +1,8 @@
 package reddit
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"github.com/jarcoal/httpmock"
 	"github.com/stretchr/testify/assert"
@@ -14,6 +16,52 @@ func TestReplyToMessage(t *testing.T) {
 	defer httpmock.DeactivateAndReset()
 
 	client := NoAuthClient
-	err := client.ReplyToMessage("6qys1q", "Reply")
+	err := client.ReplyToMessage(context.Background(), "6qys1q", "Reply")
 	assert.NoError(t, err)
 }
+
+func TestGetInboxMessages(t *testing.T) {
+	url := fmt.Sprintf("%s/message/inbox.json", baseAuthURL)
+	mockResponseFromFile(url, "test_data/message/inbox.json")
+	defer httpmock.DeactivateAndReset()
+
+	client := NoAuthClient
+	messages, err := client.GetInboxMessages(context.Background())
+	assert.NoError(t, err)
+	assert.Len(t, messages, 2)
+
+	assert.Equal(t, "dh2l0x1", messages[0].ID)
+	assert.True(t, messages[0].WasComment)
+	assert.Empty(t, messages[0].Replies)
+
+	assert.Equal(t, "qh8jb1", messages[1].ID)
+	assert.Equal(t, float64(1647455000), messages[1].CreatedUtc)
+	assert.Len(t, messages[1].Replies, 1)
+	assert.Equal(t, "qh8kc2", messages[1].Replies[0].ID)
+}
+
+func TestGetUnreadMessagesError(t *testing.T) {
+	url := fmt.Sprintf("%s/message/unread.json", baseAuthURL)
+	httpmock.Activate()
+	httpmock.RegisterResponder("GET", url, httpmock.NewStringResponder(403, `{"message": "Forbidden", "error": 403}`))
+	defer httpmock.DeactivateAndReset()
+
+	client := NoAuthClient
+	_, err := client.GetUnreadMessages(context.Background())
+	assert.Error(t, err)
+	assert.True(t, errors.Is(err, ErrForbidden))
+}
+
+func TestGetMentionMessages(t *testing.T) {
+	url := fmt.Sprintf("%s/message/mentions.json", baseAuthURL)
+	mockResponseFromFile(url, "test_data/message/inbox.json")
+	defer httpmock.DeactivateAndReset()
+
+	client := NoAuthClient
+	messages, err := client.GetMentionMessages(context.Background())
+	assert.NoError(t, err)
+	assert.Len(t, messages, 2)
+	assert.Equal(t, "Go 1.18 is released", messages[0].LinkTitle)
+	assert.Equal(t, "golang", messages[0].Subreddit)
+	assert.Equal(t, "t4_qh8iyz", messages[1].FirstMessageName)
+}
@@ -0,0 +1,73 @@
+package reddit
+
+import (
+	"net/http"
+	"time"
+)
+
+// retryBackoff is the wait before the first retry of a request, it doubles with every retry.
+var retryBackoff = 500 * time.Millisecond
+
+// Logger receives the messages of a client, *log.Logger is one.
+type Logger interface {
+	Printf(format string, v ...interface{})
+}
+
+// Option configures a Client created with New.
+type Option func(*Client)
+
+// New creates a client for the Reddit API. Without options, it sends its requests
+// with a new http.Client to www.reddit.com and oauth.reddit.com, and never retries them.
+func New(opts ...Option) *Client {
+	c := &Client{
+		http:        new(http.Client),
+		baseURL:     baseURL,
+		baseAuthURL: baseAuthURL,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// WithBaseURL sets the URL of the Reddit compatible server listings, comments, users and searches are requested from, ie: "http://localhost:8080".
+func WithBaseURL(url string) Option {
+	return func(c *Client) {
+		c.baseURL = url
+	}
+}
+
+// WithAuthBaseURL sets the URL of the server the endpoints requiring OAuth are requested from.
+func WithAuthBaseURL(url string) Option {
+	return func(c *Client) {
+		c.baseAuthURL = url
+	}
+}
+
+// WithHTTPClient sets the HTTP client requests are sent through, ie: one authorizing them.
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.http = httpClient
+	}
+}
+
+// WithUserAgent sets the User-Agent header of every request. Reddit asks for one identifying the application.
+func WithUserAgent(userAgent string) Option {
+	return func(c *Client) {
+		c.userAgent = userAgent
+	}
+}
+
+// WithRetries sets how many times GET requests are retried after a server error or a broken connection.
+func WithRetries(retries int) Option {
+	return func(c *Client) {
+		c.retries = retries
+	}
+}
+
+// WithLogger sets where the client reports retried requests.
+func WithLogger(logger Logger) Option {
+	return func(c *Client) {
+		c.logger = logger
+	}
+}
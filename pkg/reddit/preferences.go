@@ -2,10 +2,9 @@ package reddit
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
-	"errors"
 	"fmt"
-	"net/http"
 )
 
 // Preferences contains a user's account preferences.
@@ -67,25 +66,11 @@ type Preferences struct {
 }
 
 // GetMyPreferences retrieves the accouunt preferences for the currently authenticated user. Requires the 'identity' OAuth scope.
-func (c *Client) GetMyPreferences() (*Preferences, error) {
-	url := fmt.Sprintf("%s/api/v1/me/preferences", baseAuthURL)
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		return nil, err
-	}
-
-	req.Header.Add("User-Agent", c.userAgent)
-
-	resp, err := c.http.Do(req)
-	if err != nil {
-		return nil, err
-	} else if resp.StatusCode >= 400 {
-		return nil, errors.New(fmt.Sprintf("HTTP Status Code: %d", resp.StatusCode))
-	}
-	defer resp.Body.Close()
+func (c *Client) GetMyPreferences(ctx context.Context) (*Preferences, error) {
+	url := fmt.Sprintf("%s/api/v1/me/preferences", c.baseAuthURL)
 
 	var preferences Preferences
-	err = json.NewDecoder(resp.Body).Decode(&preferences)
+	err := c.get(ctx, url, &preferences)
 	if err != nil {
 		return nil, err
 	}
@@ -94,28 +79,19 @@ func (c *Client) GetMyPreferences() (*Preferences, error) {
 }
 
 // UpdateMyPreferences updates the accouunt preferences for the currently authenticated user. Requires the 'account' OAuth scope.
-func (c *Client) UpdateMyPreferences(preferences *Preferences) (*Preferences, error) {
-	url := fmt.Sprintf("%s/api/v1/me/preferences", baseAuthURL)
+func (c *Client) UpdateMyPreferences(ctx context.Context, preferences *Preferences) (*Preferences, error) {
+	url := fmt.Sprintf("%s/api/v1/me/preferences", c.baseAuthURL)
 	buffer := new(bytes.Buffer)
 	json.NewEncoder(buffer).Encode(preferences)
-	req, err := http.NewRequest("PATCH", url, buffer)
+	req, err := c.NewRequest(ctx, "PATCH", url, buffer)
 	if err != nil {
 		return nil, err
 	}
 
-	req.Header.Add("User-Agent", c.userAgent)
 	req.Header.Add("Content-Type", "application/json")
 
-	resp, err := c.http.Do(req)
-	if err != nil {
-		return nil, err
-	} else if resp.StatusCode >= 400 {
-		return nil, errors.New(fmt.Sprintf("HTTP Status Code: %d", resp.StatusCode))
-	}
-	defer resp.Body.Close()
-
 	var updatedPreferences Preferences
-	err = json.NewDecoder(resp.Body).Decode(&updatedPreferences)
+	_, err = c.Do(req, &updatedPreferences)
 	if err != nil {
 		return nil, err
 	}
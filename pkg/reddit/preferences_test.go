@@ -1,6 +1,7 @@
 package reddit
 
 import (
+	"context"
 	"fmt"
 	"github.com/jarcoal/httpmock"
 	"github.com/stretchr/testify/assert"
@@ -14,7 +15,7 @@ func TestGetMyPreferences(t *testing.T) {
 	defer httpmock.DeactivateAndReset()
 
 	client := NoAuthClient
-	preferences, err := client.GetMyPreferences()
+	preferences, err := client.GetMyPreferences(context.Background())
 	assert.NoError(t, err)
 	assert.Equal(t, preferences.NumComments, 200)
 	assert.Equal(t, preferences.Lang, "en")
@@ -30,6 +31,6 @@ func TestUpdateMyPreferences(t *testing.T) {
 
 	client := NoAuthClient
 	preferences := &Preferences{NumComments: 600}
-	_, err := client.UpdateMyPreferences(preferences)
+	_, err := client.UpdateMyPreferences(context.Background(), preferences)
 	assert.NoError(t, err)
 }
@@ -2,105 +2,173 @@
 package reddit
 
 import (
-	"bytes"
+	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"net"
 	"net/http"
 	"net/url"
 	"strconv"
+	"strings"
+	"time"
 )
 
 const (
 	baseAuthURL = "https://oauth.reddit.com"
-	baseURL     = "http://reddit.com"
+	baseURL     = "https://www.reddit.com"
 )
 
 // Client is the client for interacting with the Reddit API.
 type Client struct {
-	http      *http.Client
-	userAgent string
+	http        *http.Client
+	userAgent   string
+	baseURL     string
+	baseAuthURL string
+	retries     int
+	logger      Logger
 }
 
 // NoAuthClient is the unauthenticated client for interacting with the Reddit API.
-var NoAuthClient = &Client{
-	http: new(http.Client),
+var NoAuthClient = New()
+
+// NewClient creates a client that sends its requests through the given HTTP client with the supplied user agent.
+func NewClient(httpClient *http.Client, userAgent string) *Client {
+	return New(WithHTTPClient(httpClient), WithUserAgent(userAgent))
 }
 
-func (c *Client) commentOnThing(fullname string, text string) error {
-	data := url.Values{}
-	data.Set("thing_id", fullname)
-	data.Set("text", text)
-	data.Set("api_type", "json")
-	url := fmt.Sprintf("%s/api/comment", baseAuthURL)
-	req, err := http.NewRequest("POST", url, bytes.NewBufferString(data.Encode()))
+// NewRequest creates a request carrying the user agent of the client.
+// A urlStr starting with a slash is relative to the base URL of the client.
+func (c *Client) NewRequest(ctx context.Context, method string, urlStr string, body io.Reader) (*http.Request, error) {
+	if strings.HasPrefix(urlStr, "/") {
+		urlStr = c.baseURL + urlStr
+	}
 
+	req, err := http.NewRequestWithContext(ctx, method, urlStr, body)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	req.Header.Add("User-Agent", c.userAgent)
-	req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
-	req.Header.Add("Content-Length", strconv.Itoa(len(data.Encode())))
 
-	resp, err := c.http.Do(req)
+	return req, nil
+}
+
+// Do sends req and decodes the JSON response into v, unless v is nil. Error responses are returned as an *Error.
+// GET requests failing with a server error or a broken connection are retried as many times as the client allows.
+// The returned response is the last one received, its body is closed.
+func (c *Client) Do(req *http.Request, v interface{}) (*http.Response, error) {
+	resp, err := c.send(req)
 	if err != nil {
-		return err
-	} else if resp.StatusCode >= 400 {
-		return errors.New(fmt.Sprintf("HTTP Status Code: %d", resp.StatusCode))
+		return resp, err
 	}
 	defer resp.Body.Close()
 
-	return nil
-}
+	err = CheckResponse(resp)
+	if err != nil {
+		return resp, err
+	}
 
-func (c *Client) deleteThing(fullname string) error {
-	data := url.Values{}
-	data.Set("id", fullname)
-	url := fmt.Sprintf("%s/api/del", baseAuthURL)
-	req, err := http.NewRequest("POST", url, bytes.NewBufferString(data.Encode()))
+	if v == nil {
+		return resp, nil
+	}
+	return resp, json.NewDecoder(resp.Body).Decode(v)
+}
 
-	if err != nil {
-		return err
+// send sends req, retrying idempotent requests after transient failures.
+func (c *Client) send(req *http.Request) (*http.Response, error) {
+	attempts := 1
+	if req.Method == "GET" || req.Method == "HEAD" {
+		attempts += c.retries
 	}
 
-	req.Header.Add("User-Agent", c.userAgent)
-	req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
-	req.Header.Add("Content-Length", strconv.Itoa(len(data.Encode())))
+	backoff := retryBackoff
+	for attempt := 1; ; attempt++ {
+		resp, err := c.http.Do(req)
+		if attempt >= attempts || !retryable(resp, err) {
+			return resp, err
+		}
+
+		if err == nil {
+			err = fmt.Errorf("HTTP Status Code: %d", resp.StatusCode)
+			resp.Body.Close()
+		}
+		c.logf("reddit: retrying %s %s in %s: %s", req.Method, req.URL, backoff, err.Error())
+
+		select {
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
 
-	resp, err := c.http.Do(req)
+// retryable reports whether a request ending with resp or err may succeed when sent again.
+func retryable(resp *http.Response, err error) bool {
 	if err != nil {
-		return err
-	} else if resp.StatusCode >= 400 {
-		return errors.New(fmt.Sprintf("HTTP Status Code: %d", resp.StatusCode))
+		var opErr *net.OpError
+		return errors.As(err, &opErr) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)
 	}
-	defer resp.Body.Close()
 
-	return nil
+	switch resp.StatusCode {
+	case http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
 }
 
-func (c *Client) editThingText(fullname string, text string) error {
-	data := url.Values{}
-	data.Set("thing_id", fullname)
-	data.Set("text", text)
-	data.Set("api_type", "json")
-	url := fmt.Sprintf("%s/api/editusertext", baseAuthURL)
-	req, err := http.NewRequest("POST", url, bytes.NewBufferString(data.Encode()))
+func (c *Client) logf(format string, v ...interface{}) {
+	if c.logger != nil {
+		c.logger.Printf(format, v...)
+	}
+}
 
+// get requests url and decodes the JSON response into v.
+func (c *Client) get(ctx context.Context, url string, v interface{}) error {
+	req, err := c.NewRequest(ctx, "GET", url, nil)
 	if err != nil {
 		return err
 	}
 
-	req.Header.Add("User-Agent", c.userAgent)
-	req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
-	req.Header.Add("Content-Length", strconv.Itoa(len(data.Encode())))
+	_, err = c.Do(req, v)
+	return err
+}
 
-	resp, err := c.http.Do(req)
+// postForm posts the form data to url, the response is discarded.
+func (c *Client) postForm(ctx context.Context, url string, data url.Values) error {
+	encoded := data.Encode()
+	req, err := c.NewRequest(ctx, "POST", url, strings.NewReader(encoded))
 	if err != nil {
 		return err
-	} else if resp.StatusCode >= 400 {
-		return errors.New(fmt.Sprintf("HTTP Status Code: %d", resp.StatusCode))
 	}
-	defer resp.Body.Close()
 
-	return nil
+	req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Add("Content-Length", strconv.Itoa(len(encoded)))
+
+	_, err = c.Do(req, nil)
+	return err
+}
+
+func (c *Client) commentOnThing(ctx context.Context, fullname string, text string) error {
+	data := url.Values{}
+	data.Set("thing_id", fullname)
+	data.Set("text", text)
+	data.Set("api_type", "json")
+	return c.postForm(ctx, fmt.Sprintf("%s/api/comment", c.baseAuthURL), data)
+}
+
+func (c *Client) deleteThing(ctx context.Context, fullname string) error {
+	data := url.Values{}
+	data.Set("id", fullname)
+	return c.postForm(ctx, fmt.Sprintf("%s/api/del", c.baseAuthURL), data)
+}
+
+func (c *Client) editThingText(ctx context.Context, fullname string, text string) error {
+	data := url.Values{}
+	data.Set("thing_id", fullname)
+	data.Set("text", text)
+	data.Set("api_type", "json")
+	return c.postForm(ctx, fmt.Sprintf("%s/api/editusertext", c.baseAuthURL), data)
 }
@@ -1,8 +1,18 @@
 package reddit
 
 import (
-	"github.com/jarcoal/httpmock"
+	"bytes"
+	"context"
+	"fmt"
 	"io/ioutil"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
 )
 
 func mockResponseFromFile(url string, filepath string) {
@@ -10,3 +20,82 @@ func mockResponseFromFile(url string, filepath string) {
 	response, _ := ioutil.ReadFile(filepath)
 	httpmock.RegisterResponder("GET", url, httpmock.NewStringResponder(200, string(response)))
 }
+
+func TestNewClient(t *testing.T) {
+	url := fmt.Sprintf("%s/user/GovSchwarzenegger/about.json", baseURL)
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	var userAgent string
+	httpmock.RegisterResponder("GET", url, func(req *http.Request) (*http.Response, error) {
+		userAgent = req.Header.Get("User-Agent")
+		return httpmock.NewStringResponse(200, `{"kind": "t2", "data": {"name": "GovSchwarzenegger"}}`), nil
+	})
+
+	client := NewClient(http.DefaultClient, "USER-AGENT")
+	_, err := client.GetUserInfo(context.Background(), "GovSchwarzenegger")
+	assert.NoError(t, err)
+	assert.Equal(t, "USER-AGENT", userAgent)
+}
+
+func TestNewWithBaseURL(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/prefix/user/GovSchwarzenegger/about.json", r.URL.Path)
+		assert.Equal(t, "USER-AGENT", r.Header.Get("User-Agent"))
+		w.Write([]byte(`{"kind": "t2", "data": {"name": "GovSchwarzenegger"}}`))
+	}))
+	defer server.Close()
+
+	client := New(WithBaseURL(server.URL+"/prefix"), WithHTTPClient(server.Client()), WithUserAgent("USER-AGENT"))
+	account, err := client.GetUserInfo(context.Background(), "GovSchwarzenegger")
+	assert.NoError(t, err)
+	assert.Equal(t, "GovSchwarzenegger", account.Name)
+}
+
+func TestNewWithRetries(t *testing.T) {
+	defer func(backoff time.Duration) { retryBackoff = backoff }(retryBackoff)
+	retryBackoff = time.Millisecond
+
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests < 3 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.Write([]byte(`{"kind": "t2", "data": {"name": "GovSchwarzenegger"}}`))
+	}))
+	defer server.Close()
+
+	var logs bytes.Buffer
+	client := New(WithBaseURL(server.URL), WithHTTPClient(server.Client()), WithRetries(2), WithLogger(log.New(&logs, "", 0)))
+	_, err := client.GetUserInfo(context.Background(), "GovSchwarzenegger")
+	assert.NoError(t, err)
+	assert.Equal(t, 3, requests)
+	assert.Contains(t, logs.String(), "reddit: retrying GET")
+
+	requests = 0
+	client = New(WithBaseURL(server.URL), WithHTTPClient(server.Client()), WithRetries(1))
+	_, err = client.GetUserInfo(context.Background(), "GovSchwarzenegger")
+	assert.Error(t, err)
+	assert.Equal(t, 2, requests)
+}
+
+func TestDoReturnsResponse(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"kind": "Listing"}`))
+	}))
+	defer server.Close()
+
+	client := New(WithBaseURL(server.URL), WithHTTPClient(server.Client()))
+	req, err := client.NewRequest(context.Background(), "GET", "/r/news.json", nil)
+	assert.NoError(t, err)
+
+	var listing struct {
+		Kind string `json:"kind"`
+	}
+	resp, err := client.Do(req, &listing)
+	assert.NoError(t, err)
+	assert.Equal(t, "/r/news.json", resp.Request.URL.Path)
+	assert.Equal(t, "Listing", listing.Kind)
+}
@@ -0,0 +1,119 @@
+package reddit
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+)
+
+const (
+	// SearchSortRelevance orders search results by relevance to the query.
+	SearchSortRelevance = "relevance"
+	// SearchSortHot orders search results by hotness.
+	SearchSortHot = "hot"
+	// SearchSortTop orders search results by score.
+	SearchSortTop = "top"
+	// SearchSortNew orders search results by submission time, newest first.
+	SearchSortNew = "new"
+	// SearchSortComments orders search results by number of comments.
+	SearchSortComments = "comments"
+
+	// TimeHour limits results to the past hour.
+	TimeHour = "hour"
+	// TimeDay limits results to the past day.
+	TimeDay = "day"
+	// TimeWeek limits results to the past week.
+	TimeWeek = "week"
+	// TimeMonth limits results to the past month.
+	TimeMonth = "month"
+	// TimeYear limits results to the past year.
+	TimeYear = "year"
+	// TimeAll does not limit results by time.
+	TimeAll = "all"
+)
+
+var searchSorts = map[string]bool{
+	SearchSortRelevance: true,
+	SearchSortHot:       true,
+	SearchSortTop:       true,
+	SearchSortNew:       true,
+	SearchSortComments:  true,
+}
+
+var times = map[string]bool{
+	TimeHour:  true,
+	TimeDay:   true,
+	TimeWeek:  true,
+	TimeMonth: true,
+	TimeYear:  true,
+	TimeAll:   true,
+}
+
+// SearchOptions narrows down a link search. Empty fields use Reddit's defaults.
+type SearchOptions struct {
+	// Subreddit restricts the search to the given subreddit.
+	Subreddit string
+	// Sort is one of the SearchSort constants.
+	Sort string
+	// Time is one of the Time constants.
+	Time string
+	// After is the fullname of the last link of the previous page, to get the next one.
+	After string
+	// Count is the number of links seen on the previous pages.
+	Count int
+}
+
+// Validate reports whether the options hold values Reddit accepts.
+func (o SearchOptions) Validate() error {
+	if o.Sort != "" && !searchSorts[o.Sort] {
+		return fmt.Errorf("invalid search sort: %s", o.Sort)
+	}
+	if o.Time != "" && !times[o.Time] {
+		return fmt.Errorf("invalid search time: %s", o.Time)
+	}
+	return nil
+}
+
+// SearchLinks retrieves a listing of links matching the query.
+func (c *Client) SearchLinks(ctx context.Context, query string, opts SearchOptions) ([]*Link, error) {
+	err := opts.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	params := url.Values{}
+	params.Set("q", query)
+	params.Set("type", "link")
+	if opts.Sort != "" {
+		params.Set("sort", opts.Sort)
+	}
+	if opts.Time != "" {
+		params.Set("t", opts.Time)
+	}
+	if opts.After != "" {
+		params.Set("after", opts.After)
+		params.Set("count", strconv.Itoa(opts.Count))
+	}
+
+	path := "/search.json"
+	if opts.Subreddit != "" {
+		path = fmt.Sprintf("/r/%s/search.json", opts.Subreddit)
+		params.Set("restrict_sr", "1")
+	}
+
+	url := fmt.Sprintf("%s%s?%s", c.baseURL, path, params.Encode())
+
+	var result linkListing
+	err = c.get(ctx, url, &result)
+	if err != nil {
+		return nil, err
+	}
+
+	var links []*Link
+	for i := range result.Data.Children {
+		links = append(links, &result.Data.Children[i].Data)
+	}
+
+	return links, nil
+}
@@ -0,0 +1,52 @@
+package reddit
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSearchLinks(t *testing.T) {
+	url := fmt.Sprintf("%s/r/news/search.json?q=election&restrict_sr=1&sort=new&t=week&type=link", baseURL)
+	mockResponseFromFile(url, "test_data/link/new_links.json")
+	defer httpmock.DeactivateAndReset()
+
+	client := NoAuthClient
+	links, err := client.SearchLinks(context.Background(), "election", SearchOptions{Subreddit: "news", Sort: SearchSortNew, Time: TimeWeek})
+	assert.NoError(t, err)
+	assert.Equal(t, len(links), 3)
+}
+
+func TestSearchLinksAllOfReddit(t *testing.T) {
+	url := fmt.Sprintf("%s/search.json?q=ressdit&type=link", baseURL)
+	mockResponseFromFile(url, "test_data/link/new_links.json")
+	defer httpmock.DeactivateAndReset()
+
+	client := NoAuthClient
+	links, err := client.SearchLinks(context.Background(), "ressdit", SearchOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, len(links), 3)
+}
+
+func TestSearchLinksNextPage(t *testing.T) {
+	url := fmt.Sprintf("%s/r/golang+rust/search.json?after=t3_abc&count=25&q=generics&restrict_sr=1&sort=top&t=all&type=link", baseURL)
+	mockResponseFromFile(url, "test_data/link/new_links.json")
+	defer httpmock.DeactivateAndReset()
+
+	client := NoAuthClient
+	links, err := client.SearchLinks(context.Background(), "generics", SearchOptions{Subreddit: "golang+rust", Sort: SearchSortTop, Time: TimeAll, After: "t3_abc", Count: 25})
+	assert.NoError(t, err)
+	assert.Equal(t, len(links), 3)
+}
+
+func TestSearchLinksInvalidOptions(t *testing.T) {
+	client := NoAuthClient
+	_, err := client.SearchLinks(context.Background(), "election", SearchOptions{Sort: "newest"})
+	assert.Error(t, err)
+
+	_, err = client.SearchLinks(context.Background(), "election", SearchOptions{Time: "decade"})
+	assert.Error(t, err)
+}
@@ -1,9 +1,8 @@
 package reddit
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
-	"net/http"
 )
 
 // Subreddit contains subreddit information.
@@ -68,49 +67,37 @@ type subredditListing struct {
 }
 
 // GetDefaultSubreddits retrieves a listing of default subreddits.
-func (c *Client) GetDefaultSubreddits() ([]*Subreddit, error) {
-	return c.getSubreddits("default")
+func (c *Client) GetDefaultSubreddits(ctx context.Context) ([]*Subreddit, error) {
+	return c.getSubreddits(ctx, "default")
 }
 
 // GetGoldSubreddits retrieves a listing of gold subreddits.
-func (c *Client) GetGoldSubreddits() ([]*Subreddit, error) {
-	return c.getSubreddits("gold")
+func (c *Client) GetGoldSubreddits(ctx context.Context) ([]*Subreddit, error) {
+	return c.getSubreddits(ctx, "gold")
 }
 
 // GetNewSubreddits retrieves a listing of new subreddits.
-func (c *Client) GetNewSubreddits() ([]*Subreddit, error) {
-	return c.getSubreddits("new")
+func (c *Client) GetNewSubreddits(ctx context.Context) ([]*Subreddit, error) {
+	return c.getSubreddits(ctx, "new")
 }
 
 // GetPopularSubreddits retrieves a listing of popular subreddits.
-func (c *Client) GetPopularSubreddits() ([]*Subreddit, error) {
-	return c.getSubreddits("popular")
+func (c *Client) GetPopularSubreddits(ctx context.Context) ([]*Subreddit, error) {
+	return c.getSubreddits(ctx, "popular")
 }
 
-func (c *Client) getSubreddits(where string) ([]*Subreddit, error) {
-	url := fmt.Sprintf("%s/subreddits/%s.json", baseURL, where)
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		return nil, err
-	}
-
-	req.Header.Add("User-Agent", c.userAgent)
-
-	resp, err := c.http.Do(req)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
+func (c *Client) getSubreddits(ctx context.Context, where string) ([]*Subreddit, error) {
+	url := fmt.Sprintf("%s/subreddits/%s.json", c.baseURL, where)
 
 	var result subredditListing
-	err = json.NewDecoder(resp.Body).Decode(&result)
+	err := c.get(ctx, url, &result)
 	if err != nil {
 		return nil, err
 	}
 
 	var subreddits []*Subreddit
-	for _, subreddit := range result.Data.Children {
-		subreddits = append(subreddits, &subreddit.Data)
+	for i := range result.Data.Children {
+		subreddits = append(subreddits, &result.Data.Children[i].Data)
 	}
 
 	return subreddits, nil
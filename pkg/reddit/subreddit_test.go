@@ -1,6 +1,7 @@
 package reddit
 
 import (
+	"context"
 	"fmt"
 	"github.com/jarcoal/httpmock"
 	"github.com/stretchr/testify/assert"
@@ -13,9 +14,10 @@ func TestGetDefaultSubreddits(t *testing.T) {
 	defer httpmock.DeactivateAndReset()
 
 	client := NoAuthClient
-	subreddits, err := client.GetDefaultSubreddits()
+	subreddits, err := client.GetDefaultSubreddits(context.Background())
 	assert.NoError(t, err)
 	assert.Equal(t, len(subreddits), 3, t)
+	assert.NotEqual(t, subreddits[0].ID, subreddits[1].ID)
 }
 
 func TestGetGoldSubreddits(t *testing.T) {
@@ -24,7 +26,7 @@ func TestGetGoldSubreddits(t *testing.T) {
 	defer httpmock.DeactivateAndReset()
 
 	client := NoAuthClient
-	subreddits, err := client.GetGoldSubreddits()
+	subreddits, err := client.GetGoldSubreddits(context.Background())
 	assert.NoError(t, err)
 	assert.Equal(t, len(subreddits), 0)
 }
@@ -35,7 +37,7 @@ func TestGetNewSubreddits(t *testing.T) {
 	defer httpmock.DeactivateAndReset()
 
 	client := NoAuthClient
-	subreddits, err := client.GetNewSubreddits()
+	subreddits, err := client.GetNewSubreddits(context.Background())
 	assert.NoError(t, err)
 	assert.Equal(t, len(subreddits), 3)
 }
@@ -46,7 +48,7 @@ func TestGetPopularSubreddits(t *testing.T) {
 	defer httpmock.DeactivateAndReset()
 
 	client := NoAuthClient
-	subreddits, err := client.GetPopularSubreddits()
+	subreddits, err := client.GetPopularSubreddits(context.Background())
 	assert.NoError(t, err)
 	assert.Equal(t, len(subreddits), 3)
 }
@@ -1,6 +1,7 @@
 package reddit
 
 import (
+	"context"
 	"fmt"
 	"github.com/jarcoal/httpmock"
 	"github.com/stretchr/testify/assert"
@@ -14,7 +15,7 @@ func TestIsUsernameAvailable(t *testing.T) {
 	defer httpmock.DeactivateAndReset()
 
 	client := NoAuthClient
-	isUsernameAvailable, err := client.IsUsernameAvailable("GovSchwarzenegger")
+	isUsernameAvailable, err := client.IsUsernameAvailable(context.Background(), "GovSchwarzenegger")
 	assert.NoError(t, err)
 	assert.Equal(t, isUsernameAvailable, false)
 }
@@ -25,7 +26,7 @@ func TestGetUserInfo(t *testing.T) {
 	defer httpmock.DeactivateAndReset()
 
 	client := NoAuthClient
-	userInfo, err := client.GetUserInfo("GovSchwarzenegger")
+	userInfo, err := client.GetUserInfo(context.Background(), "GovSchwarzenegger")
 	assert.NoError(t, err)
 	assert.Equal(t, userInfo.Name, "GovSchwarzenegger")
 }
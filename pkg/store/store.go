@@ -0,0 +1,201 @@
+// Package store keeps track of when feed items were first seen, in an embedded BoltDB file.
+package store
+
+import (
+	"encoding/binary"
+	"os"
+	"sync"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// Store records, per feed, the first and last time each item was seen.
+type Store struct {
+	mu        sync.RWMutex
+	db        *bolt.DB
+	path      string
+	retention time.Duration
+}
+
+// Open opens or creates the store at path. Items that have not been seen for retention are pruned.
+func Open(path string, retention time.Duration) (*Store, error) {
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, err
+	}
+
+	return &Store{
+		db:        db,
+		path:      path,
+		retention: retention,
+	}, nil
+}
+
+// Close closes the underlying database file.
+func (s *Store) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.db.Close()
+}
+
+// entry is stored as the first seen time followed by the last seen time, both in unix seconds.
+func encodeEntry(firstSeen time.Time, lastSeen time.Time) []byte {
+	b := make([]byte, 16)
+	binary.BigEndian.PutUint64(b[:8], uint64(firstSeen.Unix()))
+	binary.BigEndian.PutUint64(b[8:], uint64(lastSeen.Unix()))
+	return b
+}
+
+func decodeEntry(b []byte) (firstSeen time.Time, lastSeen time.Time) {
+	if len(b) != 16 {
+		return time.Time{}, time.Time{}
+	}
+	firstSeen = time.Unix(int64(binary.BigEndian.Uint64(b[:8])), 0)
+	lastSeen = time.Unix(int64(binary.BigEndian.Uint64(b[8:])), 0)
+	return firstSeen, lastSeen
+}
+
+// Seen marks the items of feed as seen at now and returns the first time each of them was seen.
+func (s *Store) Seen(feed string, ids []string, now time.Time) (map[string]time.Time, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	firstSeen := make(map[string]time.Time, len(ids))
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		b, err := tx.CreateBucketIfNotExists([]byte(feed))
+		if err != nil {
+			return err
+		}
+
+		for _, id := range ids {
+			first, _ := decodeEntry(b.Get([]byte(id)))
+			if first.IsZero() {
+				first = now
+			}
+			firstSeen[id] = first
+
+			err := b.Put([]byte(id), encodeEntry(first, now))
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return firstSeen, nil
+}
+
+// Prune forgets the items that have not been seen for longer than the retention period,
+// and the feeds left without items. It returns the number of items removed.
+func (s *Store) Prune(now time.Time) (int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	cutoff := now.Add(-s.retention)
+	removed := 0
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		var emptyFeeds [][]byte
+		err := tx.ForEach(func(name []byte, b *bolt.Bucket) error {
+			// keys are collected first, deleting while iterating may skip entries
+			var expired [][]byte
+			err := b.ForEach(func(k, v []byte) error {
+				if _, last := decodeEntry(v); last.Before(cutoff) {
+					expired = append(expired, k)
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+
+			for _, k := range expired {
+				if err := b.Delete(k); err != nil {
+					return err
+				}
+			}
+			removed += len(expired)
+
+			if k, _ := b.Cursor().First(); k == nil {
+				emptyFeeds = append(emptyFeeds, name)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, name := range emptyFeeds {
+			if err := tx.DeleteBucket(name); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+
+	return removed, err
+}
+
+// Compact rewrites the database file so the space of pruned items is given back to the system.
+// Feeds cannot be marked as seen while it runs.
+func (s *Store) Compact() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tmpPath := s.path + ".compact"
+	dst, err := bolt.Open(tmpPath, 0600, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return err
+	}
+
+	// the compacted file stays open and replaces the old one in place, the store is never left without a database
+	err = bolt.Compact(dst, s.db, 1<<20)
+	if err == nil {
+		err = os.Rename(tmpPath, s.path)
+	}
+	if err != nil {
+		dst.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+
+	old := s.db
+	s.db = dst
+	return old.Close()
+}
+
+// Maintain prunes and compacts the store right away, then every interval until stop is closed,
+// so feeds and items nobody asks for anymore do not pile up across restarts.
+func (s *Store) Maintain(interval time.Duration, now func() time.Time, logf func(format string, v ...interface{}), stop <-chan struct{}) {
+	s.maintain(now(), logf)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			s.maintain(now(), logf)
+		}
+	}
+}
+
+func (s *Store) maintain(now time.Time, logf func(format string, v ...interface{})) {
+	removed, err := s.Prune(now)
+	if err != nil {
+		logf("ERROR: Unable to prune seen items: %s", err.Error())
+		return
+	}
+	if err := s.Compact(); err != nil {
+		logf("ERROR: Unable to compact seen items: %s", err.Error())
+		return
+	}
+	logf("Pruned %d seen items", removed)
+}
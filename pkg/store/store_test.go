@@ -0,0 +1,147 @@
+package store
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	bolt "go.etcd.io/bbolt"
+)
+
+func openTestStore(t *testing.T) (*Store, string) {
+	path := filepath.Join(t.TempDir(), "seen.db")
+	s, err := Open(path, time.Hour)
+	require.NoError(t, err)
+	t.Cleanup(func() { s.Close() })
+	return s, path
+}
+
+func TestSeen(t *testing.T) {
+	s, _ := openTestStore(t)
+	now := time.Unix(1700000000, 0)
+
+	first, err := s.Seen("/r/golang", []string{"a", "b"}, now)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]time.Time{"a": now, "b": now}, first)
+
+	later := now.Add(time.Minute)
+	first, err = s.Seen("/r/golang", []string{"b", "c"}, later)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]time.Time{"b": now, "c": later}, first)
+
+	// feeds are tracked apart
+	first, err = s.Seen("/r/rust", []string{"a"}, later)
+	require.NoError(t, err)
+	assert.Equal(t, later, first["a"])
+}
+
+func TestPrune(t *testing.T) {
+	s, _ := openTestStore(t)
+	now := time.Unix(1700000000, 0)
+
+	_, err := s.Seen("/r/golang", []string{"old", "kept"}, now)
+	require.NoError(t, err)
+	_, err = s.Seen("/r/rust", []string{"old"}, now)
+	require.NoError(t, err)
+	_, err = s.Seen("/r/golang", []string{"kept"}, now.Add(50*time.Minute))
+	require.NoError(t, err)
+
+	removed, err := s.Prune(now.Add(90 * time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, 2, removed)
+
+	// forgotten items are new again, the others keep their first seen time
+	later := now.Add(2 * time.Hour)
+	first, err := s.Seen("/r/golang", []string{"old", "kept"}, later)
+	require.NoError(t, err)
+	assert.Equal(t, later, first["old"])
+	assert.Equal(t, now, first["kept"])
+
+	// the feed left without items is dropped along with them
+	removed, err = s.Prune(later)
+	require.NoError(t, err)
+	assert.Zero(t, removed)
+	assert.Equal(t, []string{"/r/golang"}, feedNames(t, s))
+}
+
+func TestCompact(t *testing.T) {
+	s, path := openTestStore(t)
+	now := time.Unix(1700000000, 0)
+
+	var ids []string
+	for i := 0; i < 5000; i++ {
+		ids = append(ids, fmt.Sprintf("item%d", i))
+	}
+	_, err := s.Seen("/r/golang", ids, now)
+	require.NoError(t, err)
+	_, err = s.Seen("/r/golang", []string{"kept"}, now.Add(time.Hour))
+	require.NoError(t, err)
+	_, err = s.Prune(now.Add(90 * time.Minute))
+	require.NoError(t, err)
+
+	before, err := os.Stat(path)
+	require.NoError(t, err)
+	require.NoError(t, s.Compact())
+	after, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Less(t, after.Size(), before.Size())
+
+	// the store keeps working on the compacted file
+	first, err := s.Seen("/r/golang", []string{"kept", "new"}, now.Add(2*time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, now.Add(time.Hour), first["kept"])
+	assert.Equal(t, now.Add(2*time.Hour), first["new"])
+	_, err = os.Stat(path + ".compact")
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestCompactFailure(t *testing.T) {
+	s, path := openTestStore(t)
+	now := time.Unix(1700000000, 0)
+	_, err := s.Seen("/r/golang", []string{"a"}, now)
+	require.NoError(t, err)
+
+	// a directory in the way of the compacted file makes it fail
+	require.NoError(t, os.Mkdir(path+".compact", 0700))
+	assert.Error(t, s.Compact())
+
+	first, err := s.Seen("/r/golang", []string{"a"}, now.Add(time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, now, first["a"])
+}
+
+func TestMaintain(t *testing.T) {
+	s, _ := openTestStore(t)
+	now := time.Unix(1700000000, 0)
+
+	_, err := s.Seen("/r/golang", []string{"old"}, now)
+	require.NoError(t, err)
+	_, err = s.Seen("/r/golang?junk=1", []string{"old"}, now)
+	require.NoError(t, err)
+
+	// the store is pruned when maintenance starts, without waiting for the first interval
+	var logs []string
+	logf := func(format string, v ...interface{}) { logs = append(logs, fmt.Sprintf(format, v...)) }
+	stop := make(chan struct{})
+	close(stop)
+	s.Maintain(time.Hour, func() time.Time { return now.Add(2 * time.Hour) }, logf, stop)
+
+	assert.Equal(t, []string{"Pruned 2 seen items"}, logs)
+	assert.Empty(t, feedNames(t, s))
+}
+
+func feedNames(t *testing.T, s *Store) []string {
+	var names []string
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.ForEach(func(name []byte, _ *bolt.Bucket) error {
+			names = append(names, string(name))
+			return nil
+		})
+	})
+	require.NoError(t, err)
+	return names
+}